@@ -0,0 +1,71 @@
+package ginstarter
+
+import (
+	"github.com/acexy/golang-toolkit/logger"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	// 慢请求阈值 耗时超过该值的请求以Warn级别记录 0 表示不区分慢请求
+	SlowThreshold time.Duration
+	// 仅记录慢请求
+	SlowOnly bool
+	// 忽略记录的路由模板 与注册的路由路径(request.RouterFullPath)精确匹配 例如 /health /metrics
+	// 用于避免健康检查、监控等运维接口产生大量日志
+	IgnoreRoutes []string
+}
+
+// AccessLogMiddleware 访问日志中间件 记录请求方法、路径、状态码、耗时及客户端IP
+// 设置GinConfig.AccessLogWriter时写入该Writer 否则使用全局logrus输出
+func AccessLogMiddleware(config AccessLogConfig) Middleware {
+	ignoreRoutes := make(map[string]struct{}, len(config.IgnoreRoutes))
+	for _, v := range config.IgnoreRoutes {
+		ignoreRoutes[v] = struct{}{}
+	}
+	return func(request *Request) {
+		request.Next()
+		if _, ok := ignoreRoutes[request.RouterFullPath()]; ok {
+			return
+		}
+		latency := request.Elapsed()
+		slow := config.SlowThreshold > 0 && latency >= config.SlowThreshold
+		if config.SlowOnly && !slow {
+			return
+		}
+		log := logger.Logrus()
+		if accessLogger := stateOf(request.ctx).accessLogger; accessLogger != nil {
+			log = accessLogger
+		}
+		entry := log.WithFields(map[string]any{
+			"method":  request.HttpMethod(),
+			"path":    request.RequestPath(),
+			"status":  responseStatusCode(request.ctx),
+			"latency": latency.String(),
+			"ip":      request.RequestIP(),
+		})
+		if operation := request.OperationName(); operation != "" {
+			entry = entry.WithField("operation", operation)
+		}
+		if requestID := request.RequestID(); requestID != "" {
+			entry = entry.WithField("requestId", requestID)
+		}
+		if slow {
+			entry.Warningln("slow request")
+		} else {
+			entry.Infoln("access")
+		}
+	}
+}
+
+// 获取处理器设置的响应状态码 启用可重写状态码中间件时从重写器中读取
+func responseStatusCode(ctx *gin.Context) int {
+	if v, ok := ctx.Writer.(*responseRewriter); ok {
+		if v.statusCode != 0 {
+			return v.statusCode
+		}
+		return v.ResponseWriter.Status()
+	}
+	return ctx.Writer.Status()
+}
@@ -0,0 +1,29 @@
+package ginstarter
+
+import (
+	"github.com/acexy/golang-toolkit/util/coll"
+	"strings"
+)
+
+const headerApiVersion = "X-Api-Version"
+
+// ApiVersionMiddleware 接口版本校验中间件 校验请求头X-Api-Version是否在支持的版本列表中
+// 未携带版本请求头时使用supported中的第一个版本作为默认版本 不支持的版本将响应参数错误并列出支持的版本
+// 处理器中通过 request.ApiVersion() 获取当前请求的接口版本
+func ApiVersionMiddleware(supported ...string) Middleware {
+	if len(supported) == 0 {
+		panic("at least one supported api version is required")
+	}
+	message := "supported api versions: " + strings.Join(supported, ", ")
+	return func(request *Request) {
+		version := strings.TrimSpace(request.GetHeader(headerApiVersion))
+		if version == "" {
+			version = supported[0]
+		} else if !coll.SliceContains(supported, version) {
+			request.AbortWithResponse(RespRestBadParameters("unsupported api version " + version + ", " + message))
+			return
+		}
+		request.ctx.Set(ginCtxKeyApiVersion, version)
+		request.Next()
+	}
+}
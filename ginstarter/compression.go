@@ -0,0 +1,190 @@
+package ginstarter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultCompressionMinLength = 1024
+
+var defaultCompressionContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	// 最小压缩字节数 响应体小于该值时不压缩 默认1024
+	MinLength int
+	// 允许压缩的ContentType 支持 text/* 形式的通配 默认 text/* application/json application/javascript application/xml image/svg+xml
+	// 图片、视频等已压缩的内容不应加入该列表
+	ContentTypes []string
+	// 压缩级别 取值参考 compress/gzip(与compress/flate一致) 0 表示使用默认压缩级别
+	Level int
+}
+
+// CompressionMiddleware gzip/deflate响应压缩中间件 客户端同时支持时优先使用gzip 仅在响应体达到最小长度且ContentType在允许列表中时压缩
+// 响应体将在处理完成后统一压缩输出 处理器调用Flush时将放弃压缩 以保证流式响应及时输出
+func CompressionMiddleware(config CompressionConfig) Middleware {
+	if config.MinLength <= 0 {
+		config.MinLength = defaultCompressionMinLength
+	}
+	if len(config.ContentTypes) == 0 {
+		config.ContentTypes = defaultCompressionContentTypes
+	}
+	if config.Level == 0 {
+		config.Level = gzip.DefaultCompression
+	}
+	return func(request *Request) {
+		ctx := request.ctx
+		// 无论是否压缩 响应均随Accept-Encoding变化 避免共享缓存向不同客户端返回错误的版本
+		addVary(ctx.Writer.Header(), "Accept-Encoding")
+		encoding := negotiateCompressionEncoding(ctx.Request)
+		if ctx.Request.Method == http.MethodHead || encoding == "" {
+			request.Next()
+			return
+		}
+		writer := &bufferedResponseWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		defer func() {
+			ctx.Writer = writer.ResponseWriter
+		}()
+		request.Next()
+		if writer.passthrough {
+			return
+		}
+		data := writer.body.Bytes()
+		if shouldCompressResponse(writer.ResponseWriter, len(data), &config) {
+			compressed, err := compressBytes(encoding, data, config.Level)
+			if err == nil {
+				header := writer.Header()
+				header.Set("Content-Encoding", encoding)
+				header.Del("Content-Length")
+				data = compressed
+			}
+		}
+		if len(data) > 0 {
+			_, _ = writer.ResponseWriter.Write(data)
+		}
+	}
+}
+
+// 根据Accept-Encoding选择压缩算法 优先gzip 均不支持时返回空
+func negotiateCompressionEncoding(request *http.Request) string {
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if acceptsEncoding(request, encoding) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func compressBytes(encoding string, data []byte, level int) ([]byte, error) {
+	var compressed bytes.Buffer
+	var compressor io.WriteCloser
+	var err error
+	if encoding == "gzip" {
+		compressor, err = gzip.NewWriterLevel(&compressed, level)
+	} else {
+		// HTTP的deflate编码为zlib格式(RFC 9110 8.4.1.2) 而非原始DEFLATE数据
+		compressor, err = zlib.NewWriterLevel(&compressed, level)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = compressor.Write(data)
+	if closeErr := compressor.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// 添加Vary响应头 已包含该值时忽略
+func addVary(header http.Header, value string) {
+	for _, v := range header.Values("Vary") {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
+
+func shouldCompressResponse(writer gin.ResponseWriter, length int, config *CompressionConfig) bool {
+	if length < config.MinLength {
+		return false
+	}
+	header := writer.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	status := writer.Status()
+	if status != 0 && (status < http.StatusOK || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified) {
+		return false
+	}
+	return isCompressibleContentType(config.ContentTypes, header.Get("Content-Type"))
+}
+
+func isCompressibleContentType(allowContentTypes []string, contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if contentType == "" {
+		return false
+	}
+	for _, v := range allowContentTypes {
+		v = strings.ToLower(v)
+		if prefix, ok := strings.CutSuffix(v, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+		} else if v == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// 缓存响应体的响应写入器 用于在处理完成后再决定如何输出响应体 例如压缩或计算ETag
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body        *bytes.Buffer
+	passthrough bool
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush 流式响应 放弃缓存并输出已缓存的内容
+func (w *bufferedResponseWriter) Flush() {
+	if !w.passthrough {
+		w.passthrough = true
+		if w.body.Len() > 0 {
+			_, _ = w.ResponseWriter.Write(w.body.Bytes())
+			w.body.Reset()
+		}
+	}
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,63 @@
+package ginstarter
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompressionEncoding(t *testing.T) {
+	page := strings.Repeat("compressible text ", 200)
+	engine := newTestEngine(t, GinConfig{EnableCompression: true, Routers: []Router{&testRouter{
+		info: &RouterInfo{GroupPath: "compress"},
+		handlers: func(router *RouterWrapper) {
+			router.GET("text", func(request *Request) (Response, error) {
+				return RespTextPlain(page), nil
+			})
+			router.GET("small", func(request *Request) (Response, error) {
+				return RespTextPlain("small"), nil
+			})
+		},
+	}}})
+
+	cases := []struct {
+		acceptEncoding string
+		encoding       string
+		reader         func(r io.Reader) (io.Reader, error)
+	}{
+		{"gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate", "deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{"deflate, gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"gzip;q=0, deflate", "deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{"br", "", nil},
+		{"", "", nil},
+	}
+	for _, c := range cases {
+		recorder := doRequest(engine, http.MethodGet, "/compress/text", nil, "Accept-Encoding", c.acceptEncoding)
+		if got := recorder.Header().Get("Content-Encoding"); got != c.encoding {
+			t.Fatalf("Accept-Encoding %q expect encoding %q, got %q", c.acceptEncoding, c.encoding, got)
+		}
+		if recorder.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("Accept-Encoding %q missing Vary, got %v", c.acceptEncoding, recorder.Header().Values("Vary"))
+		}
+		var body io.Reader = recorder.Body
+		if c.reader != nil {
+			reader, err := c.reader(recorder.Body)
+			if err != nil {
+				t.Fatalf("Accept-Encoding %q invalid %s data: %v", c.acceptEncoding, c.encoding, err)
+			}
+			body = reader
+		}
+		if data, _ := io.ReadAll(body); string(data) != page {
+			t.Fatalf("Accept-Encoding %q unexpected body %q", c.acceptEncoding, data)
+		}
+	}
+
+	recorder := doRequest(engine, http.MethodGet, "/compress/small", nil, "Accept-Encoding", "gzip")
+	if recorder.Header().Get("Content-Encoding") != "" || recorder.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("small response should not be compressed but still vary, got %v", recorder.Header())
+	}
+}
@@ -0,0 +1,55 @@
+package ginstarter
+
+import (
+	"sync"
+)
+
+// 按key统计的处理中请求数
+type keyedConcurrency struct {
+	mu       sync.Mutex
+	inflight map[string]int
+	limit    int
+}
+
+func (k *keyedConcurrency) acquire(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.inflight[key] >= k.limit {
+		return false
+	}
+	k.inflight[key]++
+	return true
+}
+
+func (k *keyedConcurrency) release(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.inflight[key] <= 1 {
+		delete(k.inflight, key)
+	} else {
+		k.inflight[key]--
+	}
+}
+
+// KeyedConcurrencyLimitMiddleware 按key限制并发的中间件 例如限制每个用户同时只能执行一个导出任务
+// key 通常从认证信息中提取用户标识 返回空字符串时不做限制 同一key处理中的请求数达到limit时响应429
+// 处理结束(包括panic)后释放占用
+func KeyedConcurrencyLimitMiddleware(key func(request *Request) string, limit int) Middleware {
+	if limit <= 0 {
+		panic("concurrency limit must be greater than 0")
+	}
+	concurrency := &keyedConcurrency{inflight: make(map[string]int), limit: limit}
+	return func(request *Request) {
+		limitKey := key(request)
+		if limitKey == "" {
+			request.Next()
+			return
+		}
+		if !concurrency.acquire(limitKey) {
+			request.AbortWithResponse(RespTooManyRequests(0))
+			return
+		}
+		defer concurrency.release(limitKey)
+		request.Next()
+	}
+}
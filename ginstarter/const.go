@@ -9,6 +9,25 @@ type BizErrorMessage string
 
 const (
 	GinCtxKeyResponse = "_internal_response"
+
+	ginCtxKeyPanicContext = "_internal_panic_context"
+	ginCtxKeyStartTime    = "_internal_start_time"
+	ginCtxKeyApiVersion   = "_internal_api_version"
+	ginCtxKeyPagination   = "_internal_pagination"
+	ginCtxKeyFeatureFlags = "_internal_feature_flags"
+	ginCtxKeyNoTraceId    = "_internal_no_trace_id"
+	ginCtxKeySoftDeadline = "_internal_soft_deadline"
+	ginCtxKeyRespDecoder  = "_internal_response_decoder"
+	ginCtxKeyEngineState  = "_internal_engine_state"
+	ginCtxKeyFallback     = "_internal_group_fallback"
+	// 响应已包含完整的Rest结构 保持非200的Http状态码 不经过BadHttpCodeResolver重写
+	ginCtxKeyKeepHttpStatus = "_internal_keep_http_status"
+)
+
+const (
+	mimeJsonUtf8       = "application/json; charset=utf-8"
+	mimeProblemJson    = "application/problem+json"
+	mimePrometheusText = "text/plain; version=0.0.4; charset=utf-8"
 )
 const (
 	StatusCodeSuccess            = http.StatusOK
@@ -24,6 +43,7 @@ const (
 	StatusCodeUploadLimitExceeded  = http.StatusRequestEntityTooLarge
 	StatusCodeUnauthorized         = http.StatusUnauthorized
 	StatusCodeBadRequestParameters = http.StatusBadRequest
+	StatusCodePreconditionFailed   = http.StatusPreconditionFailed
 )
 
 const (
@@ -40,6 +60,7 @@ const (
 	statusMessageUploadLimitExceeded  = "Upload File Size Limit Exceeded"
 	statusMessageUnauthorized         = "Unauthorized Request"
 	statusMessageBadRequestParameters = "Bad Request Parameters"
+	statusMessagePreconditionFailed   = "Precondition Failed"
 )
 
 var statusCodeWithMessage = map[StatusCode]StatusMessage{
@@ -54,6 +75,7 @@ var statusCodeWithMessage = map[StatusCode]StatusMessage{
 	StatusCodeUploadLimitExceeded:  statusMessageUploadLimitExceeded,
 	StatusCodeUnauthorized:         statusMessageUnauthorized,
 	StatusCodeBadRequestParameters: statusMessageBadRequestParameters,
+	StatusCodePreconditionFailed:   statusMessagePreconditionFailed,
 }
 
 func GetStatusMessage(statusCode StatusCode) StatusMessage {
@@ -0,0 +1,47 @@
+package ginstarter
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+)
+
+// 框架管理的请求上下文数据键 使用未导出类型存储于request.Context()中 避免与业务通过SetValue设置的字符串键冲突
+// 同时可在仅持有context.Context的下游调用中获取
+type contextKey int
+
+const (
+	contextKeyRequestID contextKey = iota
+	contextKeyAuthIdentity
+	contextKeyTraceContext
+)
+
+func setContextValue(ctx *gin.Context, key contextKey, value any) {
+	ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), key, value))
+}
+
+// RequestIDFromContext 从context.Context中获取请求ID 适用于只传递了request.Context()的下游调用
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKeyRequestID).(string)
+	return requestID
+}
+
+// TraceContextFromContext 从context.Context中获取W3C链路信息 未启用TracePropagationMiddleware时返回nil
+func TraceContextFromContext(ctx context.Context) *TraceContext {
+	traceContext, _ := ctx.Value(contextKeyTraceContext).(*TraceContext)
+	return traceContext
+}
+
+// SetAuthIdentity 设置当前请求的认证身份 通常由认证中间件在校验通过后设置
+func SetAuthIdentity(request *Request, identity any) {
+	setContextValue(request.ctx, contextKeyAuthIdentity, identity)
+}
+
+// AuthIdentity 获取当前请求的认证身份 未认证时返回nil BasicAuthInterceptor校验通过后为用户名
+func AuthIdentity(request *Request) any {
+	return AuthIdentityFromContext(request.ctx.Request.Context())
+}
+
+// AuthIdentityFromContext 从context.Context中获取认证身份 未认证时返回nil
+func AuthIdentityFromContext(ctx context.Context) any {
+	return ctx.Value(contextKeyAuthIdentity)
+}
@@ -0,0 +1,123 @@
+package ginstarter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var defaultCorsAllowMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodHead,
+	http.MethodOptions,
+}
+
+// CorsConfig 跨域配置
+type CorsConfig struct {
+	// 允许的来源 * 表示允许所有来源
+	AllowOrigins []string
+	// 自定义来源校验 设置后AllowOrigins不生效
+	AllowOriginFunc func(origin string) bool
+	// 允许的请求方法 默认 GET POST PUT PATCH DELETE HEAD OPTIONS
+	AllowMethods []string
+	// 允许的请求头 不设置则允许预检请求中声明的所有请求头
+	AllowHeaders []string
+	// 允许客户端读取的响应头
+	ExposeHeaders []string
+	// 是否允许携带Cookie等凭证 启用后Access-Control-Allow-Origin将响应请求的来源而非 *
+	AllowCredentials bool
+	// 预检请求结果的缓存时间 0 表示不设置
+	MaxAge time.Duration
+}
+
+// CorsMiddleware 跨域中间件
+// 预检请求(携带Origin及Access-Control-Request-Method的OPTIONS请求)的处理优先级:
+// 1. 路由显式注册了OPTIONS处理器时 仅设置跨域响应头 由路由的OPTIONS处理器响应
+// 2. 否则由该中间件直接响应204并中断请求 不会进入路由匹配失败的404/405处理
+// 来源不被允许的预检请求将响应403 来源不被允许的普通请求不设置跨域响应头 由浏览器拦截
+func CorsMiddleware(config CorsConfig) Middleware {
+	allowMethods := config.AllowMethods
+	if len(allowMethods) == 0 {
+		allowMethods = defaultCorsAllowMethods
+	}
+	allowMethodsValue := strings.Join(allowMethods, ", ")
+	allowHeadersValue := strings.Join(config.AllowHeaders, ", ")
+	exposeHeadersValue := strings.Join(config.ExposeHeaders, ", ")
+	allowAllOrigins := false
+	for _, v := range config.AllowOrigins {
+		if v == "*" {
+			allowAllOrigins = true
+			break
+		}
+	}
+	isAllowedOrigin := func(origin string) bool {
+		if config.AllowOriginFunc != nil {
+			return config.AllowOriginFunc(origin)
+		}
+		if allowAllOrigins {
+			return true
+		}
+		for _, v := range config.AllowOrigins {
+			if strings.EqualFold(v, origin) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(request *Request) {
+		origin := request.GetHeader("Origin")
+		if origin == "" {
+			request.Next()
+			return
+		}
+		ctx := request.ctx
+		preflight := ctx.Request.Method == http.MethodOptions && request.GetHeader("Access-Control-Request-Method") != ""
+		header := ctx.Writer.Header()
+		header.Add("Vary", "Origin")
+		if !isAllowedOrigin(origin) {
+			if preflight && ctx.FullPath() == "" {
+				request.AbortWithResponse(RespAbortWithHttpStatusCode(http.StatusForbidden))
+				return
+			}
+			request.Next()
+			return
+		}
+		if allowAllOrigins && !config.AllowCredentials && config.AllowOriginFunc == nil {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if exposeHeadersValue != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeadersValue)
+			}
+			request.Next()
+			return
+		}
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethodsValue)
+		if allowHeadersValue != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeadersValue)
+		} else if requestHeaders := request.GetHeader("Access-Control-Request-Headers"); requestHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", requestHeaders)
+		}
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.FormatInt(int64(config.MaxAge/time.Second), 10))
+		}
+		// 路由显式注册了OPTIONS处理器 交由处理器响应
+		if ctx.FullPath() != "" {
+			request.Next()
+			return
+		}
+		request.AbortWithResponse(RespAbortWithHttpStatusCode(http.StatusNoContent))
+	}
+}
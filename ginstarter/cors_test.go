@@ -0,0 +1,50 @@
+package ginstarter
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCorsPreflight(t *testing.T) {
+	engine := newTestEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{CorsMiddleware(CorsConfig{AllowOrigins: []string{"https://example.com"}})},
+		Routers: []Router{&testRouter{
+			info: &RouterInfo{},
+			handlers: func(router *RouterWrapper) {
+				cors := func(request *Request) (Response, error) {
+					return RespTextPlain("cors"), nil
+				}
+				router.GET("cors", cors)
+				router.GET("cors-options", cors)
+				router.OPTIONS("cors-options", func(request *Request) (Response, error) {
+					return RespTextPlain("options handler"), nil
+				})
+			},
+		}},
+	})
+	preflight := []string{"Origin", "https://example.com", "Access-Control-Request-Method", http.MethodPost}
+
+	// 未显式注册OPTIONS路由 由跨域中间件直接响应
+	recorder := doRequest(engine, http.MethodOptions, "/cors", nil, preflight...)
+	if recorder.Code != http.StatusNoContent || recorder.Body.Len() != 0 {
+		t.Fatalf("preflight should be answered by cors middleware, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "https://example.com" || recorder.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Fatalf("missing cors headers %v", recorder.Header())
+	}
+
+	// 显式注册了OPTIONS路由 由路由处理器响应
+	recorder = doRequest(engine, http.MethodOptions, "/cors-options", nil, preflight...)
+	if recorder.Body.String() != "options handler" {
+		t.Fatalf("explicit OPTIONS handler should run, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "https://example.com" {
+		t.Fatalf("missing cors headers %v", recorder.Header())
+	}
+
+	// 不被允许的来源
+	recorder = doRequest(engine, http.MethodOptions, "/cors", nil, "Origin", "https://evil.com", "Access-Control-Request-Method", http.MethodPost)
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin should not get cors headers %v", recorder.Header())
+	}
+}
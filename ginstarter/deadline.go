@@ -0,0 +1,74 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+const headerDeadlineExceeded = "X-Deadline-Exceeded"
+
+// 在写入响应头时判断是否超出软截止时间的响应写入器
+type softDeadlineWriter struct {
+	gin.ResponseWriter
+	deadline time.Time
+	checked  bool
+}
+
+func (w *softDeadlineWriter) check() {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	if time.Now().After(w.deadline) {
+		w.Header().Set(headerDeadlineExceeded, "true")
+	}
+}
+
+func (w *softDeadlineWriter) WriteHeader(code int) {
+	w.check()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *softDeadlineWriter) WriteHeaderNow() {
+	w.check()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *softDeadlineWriter) Write(data []byte) (int, error) {
+	w.check()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *softDeadlineWriter) WriteString(s string) (int, error) {
+	w.check()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// SoftDeadlineMiddleware 软截止时间中间件 适用于宁可返回部分结果也不愿报错的聚合类接口
+// 处理链耗时超过budget时不中断处理器 仍正常输出响应 但追加响应头 X-Deadline-Exceeded: true
+// 处理器中可通过 request.SoftDeadline() 获取截止时间 以便提前结束耗时的子任务
+func SoftDeadlineMiddleware(budget time.Duration) Middleware {
+	return func(request *Request) {
+		deadline := time.Now().Add(budget)
+		request.ctx.Set(ginCtxKeySoftDeadline, deadline)
+		writer := &softDeadlineWriter{ResponseWriter: request.ctx.Writer, deadline: deadline}
+		request.ctx.Writer = writer
+		defer func() {
+			request.ctx.Writer = writer.ResponseWriter
+		}()
+		request.Next()
+		if !writer.Written() {
+			writer.check()
+		}
+	}
+}
+
+// SoftDeadline 获取SoftDeadlineMiddleware设置的软截止时间 未使用该中间件时返回false
+func (r *Request) SoftDeadline() (time.Time, bool) {
+	if v, ok := r.ctx.Get(ginCtxKeySoftDeadline); ok {
+		if deadline, ok := v.(time.Time); ok {
+			return deadline, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,141 @@
+package ginstarter
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDebugRingBodyLimit = 1024
+)
+
+// DebugRecord 调试环形缓冲中记录的请求概要信息
+type DebugRecord struct {
+	Time         time.Time     `json:"time"`
+	Method       string        `json:"method"`
+	Path         string        `json:"path"`
+	StatusCode   int           `json:"statusCode"`
+	Latency      time.Duration `json:"latency"`
+	RequestBody  string        `json:"requestBody"`
+	ResponseBody string        `json:"responseBody"`
+}
+
+// 固定容量的请求记录环形缓冲
+type debugRecordRing struct {
+	mu      sync.Mutex
+	records []*DebugRecord
+	next    int
+	full    bool
+}
+
+func newDebugRecordRing(size int) *debugRecordRing {
+	return &debugRecordRing{records: make([]*DebugRecord, size)}
+}
+
+func (r *debugRecordRing) add(record *DebugRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = record
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// 按时间从旧到新返回记录副本
+func (r *debugRecordRing) snapshot() []DebugRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []DebugRecord
+	if r.full {
+		result = make([]DebugRecord, 0, len(r.records))
+		for _, v := range r.records[r.next:] {
+			result = append(result, *v)
+		}
+	} else {
+		result = make([]DebugRecord, 0, r.next)
+	}
+	for _, v := range r.records[:r.next] {
+		result = append(result, *v)
+	}
+	return result
+}
+
+// DebugRecentRequests 获取调试环形缓冲中最近的请求记录 按时间从旧到新排列
+// 仅在DebugModule开启且设置了DebugRingSize时有数据
+func DebugRecentRequests() []DebugRecord {
+	state := currentState.Load()
+	if state == nil || state.debugRing == nil {
+		return nil
+	}
+	return state.debugRing.snapshot()
+}
+
+// 记录响应body前limit个字节的响应写入器
+type debugResponseWriter struct {
+	gin.ResponseWriter
+	body  *bytes.Buffer
+	limit int
+}
+
+func (w *debugResponseWriter) capture(data []byte) {
+	if remain := w.limit - w.body.Len(); remain > 0 {
+		if len(data) > remain {
+			data = data[:remain]
+		}
+		w.body.Write(data)
+	}
+}
+
+func (w *debugResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *debugResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// debugRingHandler 调试环形缓冲中间件 记录最近请求的概要信息 记录写入创建引擎时的ring 不受Reload影响
+func debugRingHandler(ring *debugRecordRing, bodyLimit int, ignorePath string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ignorePath != "" && ctx.Request.URL.Path == ignorePath {
+			ctx.Next()
+			return
+		}
+		start := time.Now()
+		var requestBody []byte
+		if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+			body := ctx.Request.Body
+			requestBody, _ = io.ReadAll(io.LimitReader(body, int64(bodyLimit)))
+			ctx.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(requestBody), body), body}
+		}
+		writer := &debugResponseWriter{
+			ResponseWriter: ctx.Writer,
+			body:           bytes.NewBuffer(make([]byte, 0, bodyLimit)),
+			limit:          bodyLimit,
+		}
+		ctx.Writer = writer
+		defer func() {
+			ring.add(&DebugRecord{
+				Time:         start,
+				Method:       ctx.Request.Method,
+				Path:         ctx.Request.URL.Path,
+				StatusCode:   writer.ResponseWriter.Status(),
+				Latency:      time.Since(start),
+				RequestBody:  string(requestBody),
+				ResponseBody: writer.body.String(),
+			})
+		}()
+		ctx.Next()
+	}
+}
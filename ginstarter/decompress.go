@@ -0,0 +1,100 @@
+package ginstarter
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultMaxDecompressedBytes = 10 << 20
+
+// DecompressionConfig 请求解压配置
+type DecompressionConfig struct {
+	// 解压后请求body的最大字节数 按解压后的数据流计算 用于防御压缩炸弹 默认10MB 小于0表示不限制
+	// 超过时读取body将返回*http.MaxBytesError 通过Bind系列方法读取时响应413
+	MaxDecompressedBytes int64
+}
+
+// 解压后的请求body 关闭时同时关闭解压器及原始body
+type decompressedBody struct {
+	io.Reader
+	decompressor io.Closer
+	body         io.Closer
+}
+
+func (b *decompressedBody) Close() error {
+	_ = b.decompressor.Close()
+	return b.body.Close()
+}
+
+// 按解压后的字节数限制读取 超过限制时返回*http.MaxBytesError
+type decompressLimitReader struct {
+	reader io.Reader
+	remain int64
+	limit  int64
+	err    error
+}
+
+func (r *decompressLimitReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if int64(len(p)) > r.remain+1 {
+		p = p[:r.remain+1]
+	}
+	n, err := r.reader.Read(p)
+	if int64(n) <= r.remain {
+		r.remain -= int64(n)
+		return n, err
+	}
+	n = int(r.remain)
+	r.remain = 0
+	r.err = &http.MaxBytesError{Limit: r.limit}
+	return n, r.err
+}
+
+// DecompressionMiddleware 请求解压中间件 按Content-Encoding请求头解压gzip/deflate请求body 处理器中可直接绑定解压后的数据
+// 解压后的body超过MaxDecompressedBytes时中断读取并响应413 压缩数据格式错误时响应400 不支持的编码响应415
+func DecompressionMiddleware(config ...DecompressionConfig) Middleware {
+	var c DecompressionConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.MaxDecompressedBytes == 0 {
+		c.MaxDecompressedBytes = defaultMaxDecompressedBytes
+	}
+	return func(request *Request) {
+		ctx := request.ctx
+		encoding := strings.ToLower(strings.TrimSpace(ctx.GetHeader("Content-Encoding")))
+		if encoding == "" || encoding == "identity" || ctx.Request.Body == nil || ctx.Request.Body == http.NoBody {
+			request.Next()
+			return
+		}
+		var decompressor io.ReadCloser
+		var err error
+		switch encoding {
+		case "gzip", "x-gzip":
+			decompressor, err = gzip.NewReader(ctx.Request.Body)
+		case "deflate":
+			decompressor, err = zlib.NewReader(ctx.Request.Body)
+		default:
+			request.AbortWithResponse(RespAbortWithHttpStatusCode(http.StatusUnsupportedMediaType))
+			return
+		}
+		if err != nil {
+			request.AbortWithResponse(RespRestBadParameters("bad " + encoding + " payload"))
+			return
+		}
+		var reader io.Reader = decompressor
+		if c.MaxDecompressedBytes > 0 {
+			reader = &decompressLimitReader{reader: decompressor, remain: c.MaxDecompressedBytes, limit: c.MaxDecompressedBytes}
+		}
+		ctx.Request.Body = &decompressedBody{Reader: reader, decompressor: decompressor, body: ctx.Request.Body}
+		ctx.Request.ContentLength = -1
+		ctx.Request.Header.Del("Content-Encoding")
+		ctx.Request.Header.Del("Content-Length")
+		request.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package ginstarter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 构造name字段长度为size的gzip压缩json body
+func gzipJsonBody(t *testing.T, size int) *bytes.Buffer {
+	body := &bytes.Buffer{}
+	writer := gzip.NewWriter(body)
+	_, err := writer.Write([]byte(`{"name":"` + strings.Repeat("a", size) + `"}`))
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// 绑定解压后的json body 响应name的长度
+func newDecompressEngine(t *testing.T) *gin.Engine {
+	return newHandlerEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{DecompressionMiddleware(DecompressionConfig{MaxDecompressedBytes: 64 * 1024})},
+	}, http.MethodPost, "decompress", func(request *Request) (Response, error) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := request.BindBodyJson(&body); err != nil {
+			return nil, err
+		}
+		return RespTextPlain(strconv.Itoa(len(body.Name))), nil
+	})
+}
+
+func TestDecompression(t *testing.T) {
+	engine := newDecompressEngine(t)
+	recorder := doRequest(engine, http.MethodPost, "/decompress", gzipJsonBody(t, 1024), "Content-Type", "application/json", "Content-Encoding", "gzip")
+	if recorder.Body.String() != "1024" {
+		t.Fatalf("gzip body should be decompressed, got %s", recorder.Body.String())
+	}
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	engine := newDecompressEngine(t)
+	// 压缩后约1KB 解压后1MB 超过64KB的限制
+	body := gzipJsonBody(t, 1<<20)
+	if body.Len() > 4096 {
+		t.Fatalf("crafted payload should be small, got %d bytes", body.Len())
+	}
+	recorder := doRequest(engine, http.MethodPost, "/decompress", body, "Content-Type", "application/json", "Content-Encoding", "gzip")
+	if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeUploadLimitExceeded {
+		t.Fatalf("expanding payload should respond 413, got %s", recorder.Body.String())
+	}
+	if !strings.Contains(recorder.Body.String(), "exceeds the limit of 65536 bytes") {
+		t.Fatalf("missing limit message %s", recorder.Body.String())
+	}
+
+	recorder = doRequest(engine, http.MethodPost, "/decompress", strings.NewReader("not gzip"), "Content-Type", "application/json", "Content-Encoding", "gzip")
+	if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeBadRequestParameters {
+		t.Fatalf("malformed gzip should respond 400, got %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,182 @@
+package ginstarter
+
+import (
+	"bytes"
+	"container/list"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// 默认最多记录的key数量
+	defaultDedupMaxEntries = 10000
+	// 清理过期记录的间隔 与ttl无关 避免较长ttl时过期记录长时间堆积
+	dedupSweepInterval = time.Second
+)
+
+// 已完成请求的响应快照
+type dedupEntry struct {
+	key        string
+	element    *list.Element
+	done       chan struct{}
+	statusCode int
+	header     http.Header
+	body       []byte
+	expireAt   time.Time
+}
+
+type dedupStore struct {
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+	// 按创建顺序排列的记录 超过maxEntries时淘汰最早创建的记录
+	order      *list.List
+	ttl        time.Duration
+	maxEntries int
+	lastSweep  time.Time
+}
+
+func (s *dedupStore) remove(entry *dedupEntry) {
+	delete(s.entries, entry.key)
+	s.order.Remove(entry.element)
+}
+
+// 获取key对应的记录 不存在或已过期时创建新记录 owner 标识当前请求负责执行处理器
+func (s *dedupStore) acquire(key string) (entry *dedupEntry, owner bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= dedupSweepInterval {
+		for _, v := range s.entries {
+			if !v.expireAt.IsZero() && now.After(v.expireAt) {
+				s.remove(v)
+			}
+		}
+		s.lastSweep = now
+	}
+	if v, ok := s.entries[key]; ok {
+		if v.expireAt.IsZero() || now.Before(v.expireAt) {
+			return v, false
+		}
+		s.remove(v)
+	}
+	for len(s.entries) >= s.maxEntries {
+		// 被淘汰的处理中记录不影响已在等待的重复请求
+		s.remove(s.order.Front().Value.(*dedupEntry))
+	}
+	entry = &dedupEntry{key: key, done: make(chan struct{})}
+	entry.element = s.order.PushBack(entry)
+	s.entries[key] = entry
+	return entry, true
+}
+
+func (s *dedupStore) complete(key string, entry *dedupEntry, store bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if store {
+		entry.expireAt = time.Now().Add(s.ttl)
+	} else if s.entries[key] == entry {
+		s.remove(entry)
+	}
+	close(entry.done)
+}
+
+// DedupMiddleware 重复提交合并中间件 在ttl时间内相同key的请求直接返回首次请求的响应 不再执行处理器
+// 适用于双击等短时间内的重复提交 相当于针对未携带幂等键客户端的短时幂等
+// key 返回空字符串时不做合并处理 首次请求处理中到达的重复请求将等待其完成后返回相同的响应 等待期间客户端断开连接时直接结束
+// 首次请求发生panic时不记录响应 后续请求将重新执行处理器 重复请求不回放Set-Cookie及请求ID、链路等与单次请求绑定的响应头
+// maxEntries 最多记录的key数量 默认10000 超过时淘汰最早创建的记录
+func DedupMiddleware(key func(request *Request) string, ttl time.Duration, maxEntries ...int) Middleware {
+	store := &dedupStore{entries: make(map[string]*dedupEntry), order: list.New(), ttl: ttl, maxEntries: defaultDedupMaxEntries}
+	if len(maxEntries) > 0 && maxEntries[0] > 0 {
+		store.maxEntries = maxEntries[0]
+	}
+	return func(request *Request) {
+		dedupKey := key(request)
+		if dedupKey == "" {
+			request.Next()
+			return
+		}
+		ctx := request.ctx
+		entry, owner := store.acquire(dedupKey)
+		if !owner {
+			select {
+			case <-entry.done:
+			case <-ctx.Request.Context().Done():
+				// 客户端已断开连接 不再等待首次请求完成
+				ctx.Abort()
+				return
+			}
+			if entry.header != nil {
+				replayDedupEntry(ctx, entry)
+				return
+			}
+			request.Next()
+			return
+		}
+		writer := &dedupResponseWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		completed := false
+		defer func() {
+			ctx.Writer = writer.ResponseWriter
+			if completed {
+				statusCode := writer.ResponseWriter.Status()
+				if statusCode == 0 {
+					statusCode = http.StatusOK
+				}
+				entry.statusCode = statusCode
+				entry.header = writer.Header().Clone()
+				entry.body = writer.body.Bytes()
+			}
+			store.complete(dedupKey, entry, completed)
+		}()
+		request.Next()
+		completed = true
+	}
+}
+
+// 与单次请求绑定的响应头 不从首次请求的响应中回放
+var dedupPerRequestHeaders = map[string]struct{}{
+	"Set-Cookie":   {},
+	"X-Request-Id": {},
+	"Trace-Id":     {},
+	"Traceparent":  {},
+	"Tracestate":   {},
+	"Date":         {},
+}
+
+// 回放首次请求的响应 当前请求已设置的响应头(例如请求ID)优先 不回放与单次请求绑定的响应头
+func replayDedupEntry(ctx *gin.Context, entry *dedupEntry) {
+	header := ctx.Writer.Header()
+	for k, v := range entry.header {
+		if _, ok := dedupPerRequestHeaders[k]; ok {
+			continue
+		}
+		if _, ok := header[k]; ok {
+			continue
+		}
+		header[k] = append([]string(nil), v...)
+	}
+	ctx.Status(entry.statusCode)
+	if len(entry.body) > 0 {
+		_, _ = ctx.Writer.Write(entry.body)
+	}
+	ctx.Abort()
+}
+
+// 记录响应body的响应写入器
+type dedupResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *dedupResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *dedupResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
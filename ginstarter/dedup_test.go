@@ -0,0 +1,117 @@
+package ginstarter
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 按查询参数k合并请求的引擎 handler返回nil时响应执行次数
+func newDedupEngine(t *testing.T, ttl time.Duration, maxEntries int, handler func(request *Request, count int64) Response) (*gin.Engine, *atomic.Int64) {
+	count := &atomic.Int64{}
+	engine := newTestEngine(t, GinConfig{Routers: []Router{&testRouter{
+		info: &RouterInfo{GroupPath: "dedup", Middlewares: []Middleware{DedupMiddleware(func(request *Request) string {
+			key, _ := request.GetQueryParam("k")
+			return key
+		}, ttl, maxEntries)}},
+		handlers: func(router *RouterWrapper) {
+			router.POST("submit", func(request *Request) (Response, error) {
+				return handler(request, count.Add(1)), nil
+			})
+		},
+	}}})
+	return engine, count
+}
+
+func TestDedupReplay(t *testing.T) {
+	engine, count := newDedupEngine(t, time.Minute, 0, func(request *Request, count int64) Response {
+		return NewCommonResp().SetDataToResponse(NewResponseDataWithStatusCode("text/plain", []byte("created"), http.StatusAccepted).
+			AddHeader("X-Biz", "1").AddCookie(NewCookie("sid", "first", 0, "/", "", false, true)))
+	})
+	first := doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	second := doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	if count.Load() != 1 {
+		t.Fatalf("duplicate request should not execute the handler, executed %d", count.Load())
+	}
+	if second.Code != first.Code || second.Body.String() != "created" || second.Header().Get("X-Biz") != "1" {
+		t.Fatalf("duplicate should replay the first response, got %d %s %v", second.Code, second.Body.String(), second.Header())
+	}
+	if len(second.Header().Values("Set-Cookie")) != 0 {
+		t.Fatalf("Set-Cookie should not be replayed, got %v", second.Header().Values("Set-Cookie"))
+	}
+	doRequest(engine, http.MethodPost, "/dedup/submit", nil)
+	doRequest(engine, http.MethodPost, "/dedup/submit", nil)
+	if count.Load() != 3 {
+		t.Fatalf("requests without key should not be merged, executed %d", count.Load())
+	}
+}
+
+func TestDedupExpire(t *testing.T) {
+	engine, count := newDedupEngine(t, 50*time.Millisecond, 0, func(request *Request, count int64) Response {
+		return RespTextPlain("ok")
+	})
+	doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	time.Sleep(80 * time.Millisecond)
+	doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	if count.Load() != 2 {
+		t.Fatalf("expired key should execute the handler again, executed %d", count.Load())
+	}
+}
+
+func TestDedupOwnerPanic(t *testing.T) {
+	engine, count := newDedupEngine(t, time.Minute, 0, func(request *Request, count int64) Response {
+		if count == 1 {
+			panic("first request failed")
+		}
+		return RespTextPlain("ok")
+	})
+	doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	recorder := doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	if count.Load() != 2 || recorder.Body.String() != "ok" {
+		t.Fatalf("request after a panic should execute the handler again, executed %d %s", count.Load(), recorder.Body.String())
+	}
+}
+
+func TestDedupEvict(t *testing.T) {
+	engine, count := newDedupEngine(t, time.Minute, 2, func(request *Request, count int64) Response {
+		return RespTextPlain("ok")
+	})
+	for _, key := range []string{"a", "b", "a", "c", "b", "a"} {
+		doRequest(engine, http.MethodPost, "/dedup/submit?k="+key, nil)
+	}
+	// a b 执行 a合并 c淘汰a b合并 a重新执行并淘汰b
+	if count.Load() != 4 {
+		t.Fatalf("oldest key should be evicted over maxEntries, executed %d", count.Load())
+	}
+}
+
+func TestDedupWaiterCancel(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	engine, _ := newDedupEngine(t, time.Minute, 0, func(request *Request, count int64) Response {
+		close(entered)
+		<-release
+		return RespTextPlain("ok")
+	})
+	defer close(release)
+	go doRequest(engine, http.MethodPost, "/dedup/submit?k=a", nil)
+	<-entered
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest(http.MethodPost, "/dedup/submit?k=a", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		engine.ServeHTTP(httptest.NewRecorder(), request)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("duplicate request should stop waiting after the client disconnected")
+	}
+}
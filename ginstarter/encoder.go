@@ -0,0 +1,51 @@
+package ginstarter
+
+import (
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// ResponseEncoder 响应数据编码器 将数据编码为指定ContentType的[]byte
+type ResponseEncoder func(data any) ([]byte, error)
+
+// ResponseEncoderRegistry 按ContentType注册的响应编码器 用于request.Respond根据Accept协商响应格式
+// 注册顺序即优先级 客户端未携带Accept或均不匹配时使用第一个注册的编码器
+type ResponseEncoderRegistry struct {
+	contentTypes []string
+	encoders     map[string]ResponseEncoder
+}
+
+// NewResponseEncoderRegistry 创建空的响应编码器注册表
+func NewResponseEncoderRegistry() *ResponseEncoderRegistry {
+	return &ResponseEncoderRegistry{encoders: make(map[string]ResponseEncoder)}
+}
+
+// Register 注册ContentType对应的编码器 重复注册将覆盖编码器但保留原有优先级
+func (r *ResponseEncoderRegistry) Register(contentType string, encoder ResponseEncoder) *ResponseEncoderRegistry {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if _, ok := r.encoders[contentType]; !ok {
+		r.contentTypes = append(r.contentTypes, contentType)
+	}
+	r.encoders[contentType] = encoder
+	return r
+}
+
+// ContentTypes 已注册的ContentType 按优先级排序
+func (r *ResponseEncoderRegistry) ContentTypes() []string {
+	return append([]string(nil), r.contentTypes...)
+}
+
+// 未配置注册表时使用的默认编码器 Json(使用ResponseDataStructDecoder)及Xml
+var defaultResponseEncoderRegistry = NewResponseEncoderRegistry().
+	Register(gin.MIMEJSON, func(data any) ([]byte, error) {
+		return currentConfig().ResponseDataStructDecoder.Decode(data)
+	}).
+	Register(gin.MIMEXML, xml.Marshal)
+
+func responseEncoderRegistry(ctx *gin.Context) *ResponseEncoderRegistry {
+	if registry := configOf(ctx).ResponseEncoderRegistry; registry != nil && len(registry.contentTypes) > 0 {
+		return registry
+	}
+	return defaultResponseEncoderRegistry
+}
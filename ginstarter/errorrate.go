@@ -0,0 +1,131 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 滑动窗口划分的桶数
+const routeErrorRateBuckets = 10
+
+type routeErrorBucket struct {
+	index    int64
+	requests int64
+	errors   int64
+}
+
+// 单个路由模板的滑动窗口计数
+type routeErrorWindow struct {
+	mu      sync.Mutex
+	buckets [routeErrorRateBuckets]routeErrorBucket
+}
+
+func (w *routeErrorWindow) add(index int64, isError bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	bucket := &w.buckets[index%routeErrorRateBuckets]
+	if bucket.index != index {
+		*bucket = routeErrorBucket{index: index}
+	}
+	bucket.requests++
+	if isError {
+		bucket.errors++
+	}
+}
+
+func (w *routeErrorWindow) rate(index int64) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var requests, errors int64
+	for _, bucket := range w.buckets {
+		if bucket.index > index-routeErrorRateBuckets && bucket.index <= index {
+			requests += bucket.requests
+			errors += bucket.errors
+		}
+	}
+	if requests == 0 {
+		return 0
+	}
+	return float64(errors) / float64(requests)
+}
+
+// RouteErrorRates 按路由模板统计滑动窗口内错误率 用于SLO告警等无需外部指标系统的场景
+// 每个实例独立统计 可同时用于全局及多个Router 同一请求经过多次注册的同一实例时将重复计数
+type RouteErrorRates struct {
+	bucketSize time.Duration
+	// key为路由模板 仅统计已注册的路由 数量有限
+	routes sync.Map
+}
+
+// NewRouteErrorRates 创建路由错误率统计 通过Middleware注册统计中间件 通过Rate获取错误率
+// window 为滑动窗口时长 小于等于0时默认1分钟
+func NewRouteErrorRates(window time.Duration) *RouteErrorRates {
+	if window <= 0 {
+		window = time.Minute
+	}
+	bucketSize := window / routeErrorRateBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	return &RouteErrorRates{bucketSize: bucketSize}
+}
+
+func (r *RouteErrorRates) bucketIndex(now time.Time) int64 {
+	return now.UnixNano() / int64(r.bucketSize)
+}
+
+func (r *RouteErrorRates) add(template string, now time.Time, isError bool) {
+	value, ok := r.routes.Load(template)
+	if !ok {
+		value, _ = r.routes.LoadOrStore(template, &routeErrorWindow{})
+	}
+	value.(*routeErrorWindow).add(r.bucketIndex(now), isError)
+}
+
+// Middleware 统计中间件 以处理器设置的最终响应状态码判断 5xx及响应500的panic计为错误 参数校验等4xx的panic不计为错误
+// 客户端断开连接导致的panic及未匹配路由的请求不统计
+func (r *RouteErrorRates) Middleware() Middleware {
+	return func(request *Request) {
+		defer func() {
+			if panicError := recover(); panicError != nil {
+				if template := request.RouterFullPath(); template != "" && !isClientDisconnectPanic(request.ctx, panicError) {
+					// 与recoverHandler一致 参数校验等内部panic按其状态码判断 其余panic响应500
+					status, _, _ := resolvePanic(panicError)
+					r.add(template, time.Now(), status == 0 || status >= http.StatusInternalServerError)
+				}
+				panic(panicError)
+			}
+		}()
+		request.Next()
+		template := request.RouterFullPath()
+		if template == "" {
+			return
+		}
+		r.add(template, time.Now(), responseStatusCode(request.ctx) >= http.StatusInternalServerError)
+	}
+}
+
+// 客户端断开连接导致的panic 不属于服务端错误 不计入统计
+func isClientDisconnectPanic(ctx *gin.Context, panicError any) bool {
+	if ctx.Request.Context().Err() != nil {
+		return true
+	}
+	err, ok := panicError.(error)
+	return ok && isBrokenPipeError(err)
+}
+
+// Rate 获取路由模板(与request.RouterFullPath一致 例如 /user/:id)在滑动窗口内的错误率 取值0~1
+// 窗口内无请求时返回0
+func (r *RouteErrorRates) Rate(template string) float64 {
+	return r.rate(template, time.Now())
+}
+
+func (r *RouteErrorRates) rate(template string, now time.Time) float64 {
+	value, ok := r.routes.Load(template)
+	if !ok {
+		return 0
+	}
+	return value.(*routeErrorWindow).rate(r.bucketIndex(now))
+}
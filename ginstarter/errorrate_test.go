@@ -0,0 +1,84 @@
+package ginstarter
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRouteErrorRatesWindow(t *testing.T) {
+	rates := NewRouteErrorRates(10 * time.Second)
+	start := time.Unix(1_000_000, 0)
+	rates.add("/a", start, true)
+	rates.add("/a", start.Add(3*time.Second), false)
+	rates.add("/a", start.Add(5*time.Second), false)
+	rates.add("/a", start.Add(5*time.Second), true)
+	if rate := rates.rate("/a", start.Add(5*time.Second)); rate != 0.5 {
+		t.Fatalf("expect 0.5 within window, got %v", rate)
+	}
+	// 首个桶滑出窗口
+	if rate := rates.rate("/a", start.Add(10*time.Second)); math.Abs(rate-1.0/3) > 1e-9 {
+		t.Fatalf("expect 1/3 after the first bucket left the window, got %v", rate)
+	}
+	// 第13秒复用第3秒的桶 旧数据不应残留 窗口内仅剩第5秒及第13秒的请求
+	rates.add("/a", start.Add(13*time.Second), false)
+	if rate := rates.rate("/a", start.Add(13*time.Second)); math.Abs(rate-1.0/3) > 1e-9 {
+		t.Fatalf("expect 1/3 after bucket rollover, got %v", rate)
+	}
+	if rate := rates.rate("/a", start.Add(30*time.Second)); rate != 0 {
+		t.Fatalf("expect 0 once all buckets left the window, got %v", rate)
+	}
+	if rate := rates.rate("/unknown", start); rate != 0 {
+		t.Fatalf("unknown route should report 0, got %v", rate)
+	}
+}
+
+func TestRouteErrorRatesMiddleware(t *testing.T) {
+	global := NewRouteErrorRates(time.Minute)
+	group := NewRouteErrorRates(time.Minute)
+	engine := newTestEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{global.Middleware()},
+		Routers: []Router{&testRouter{
+			info: &RouterInfo{GroupPath: "rate", Middlewares: []Middleware{group.Middleware()}},
+			handlers: func(router *RouterWrapper) {
+				router.GET("bad-param", func(request *Request) (Response, error) {
+					request.MustGetQueryParam("id")
+					return RespTextPlain("ok"), nil
+				})
+				router.GET("boom", func(request *Request) (Response, error) {
+					panic(errors.New("boom"))
+				})
+				router.GET("status/:code", func(request *Request) (Response, error) {
+					code := request.GetPathParam("code")
+					if code == "500" {
+						return RespAbortWithHttpStatusCode(http.StatusInternalServerError), nil
+					}
+					return RespTextPlain("ok"), nil
+				})
+			},
+		}},
+	})
+	doRequest(engine, http.MethodGet, "/rate/bad-param", nil)
+	doRequest(engine, http.MethodGet, "/rate/bad-param?id=1", nil)
+	doRequest(engine, http.MethodGet, "/rate/boom", nil)
+	doRequest(engine, http.MethodGet, "/rate/status/500", nil)
+	doRequest(engine, http.MethodGet, "/rate/status/200", nil)
+	doRequest(engine, http.MethodGet, "/not-found", nil)
+
+	for _, rates := range []*RouteErrorRates{global, group} {
+		if rate := rates.Rate("/rate/bad-param"); rate != 0 {
+			t.Fatalf("4xx panic should not count as error, got %v", rate)
+		}
+		if rate := rates.Rate("/rate/boom"); rate != 1 {
+			t.Fatalf("500 panic should count as error, got %v", rate)
+		}
+		if rate := rates.Rate("/rate/status/:code"); rate != 0.5 {
+			t.Fatalf("expect 0.5 for the route template, got %v", rate)
+		}
+	}
+	if rate := global.Rate("/not-found"); rate != 0 {
+		t.Fatalf("unmatched route should not be counted, got %v", rate)
+	}
+}
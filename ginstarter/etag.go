@@ -0,0 +1,106 @@
+package ginstarter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETagConfig ETag配置
+type ETagConfig struct {
+	// 使用弱校验ETag W/"..."
+	Weak bool
+	// 仅对指定的路由模板生效 与注册的路由路径(request.RouterFullPath)精确匹配 不设置则对所有路由生效
+	IncludeRoutes []string
+	// 不生效的路由模板 优先于IncludeRoutes
+	ExcludeRoutes []string
+}
+
+// ETagMiddleware 自动ETag中间件 根据响应体内容计算ETag 请求的If-None-Match匹配时响应304
+// 仅作用于GET/HEAD请求且响应状态码为2xx、长度确定(处理器未调用Flush)的响应 处理器已设置ETag时不覆盖
+func ETagMiddleware(config ETagConfig) Middleware {
+	includeRoutes := make(map[string]struct{}, len(config.IncludeRoutes))
+	for _, v := range config.IncludeRoutes {
+		includeRoutes[v] = struct{}{}
+	}
+	excludeRoutes := make(map[string]struct{}, len(config.ExcludeRoutes))
+	for _, v := range config.ExcludeRoutes {
+		excludeRoutes[v] = struct{}{}
+	}
+	return func(request *Request) {
+		ctx := request.ctx
+		method := ctx.Request.Method
+		if method != http.MethodGet && method != http.MethodHead {
+			request.Next()
+			return
+		}
+		route := request.RouterFullPath()
+		if _, ok := excludeRoutes[route]; ok {
+			request.Next()
+			return
+		}
+		if len(includeRoutes) > 0 {
+			if _, ok := includeRoutes[route]; !ok {
+				request.Next()
+				return
+			}
+		}
+		writer := &bufferedResponseWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		defer func() {
+			ctx.Writer = writer.ResponseWriter
+		}()
+		request.Next()
+		if writer.passthrough {
+			return
+		}
+		data := writer.body.Bytes()
+		status := writer.ResponseWriter.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		header := writer.Header()
+		if status >= http.StatusOK && status < http.StatusMultipleChoices {
+			etag := header.Get("ETag")
+			if etag == "" {
+				etag = computeETag(data, config.Weak)
+				header.Set("ETag", etag)
+			}
+			if matchETag(ctx.GetHeader("If-None-Match"), etag) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		if len(data) > 0 {
+			_, _ = writer.ResponseWriter.Write(data)
+		}
+	}
+}
+
+func computeETag(data []byte, weak bool) string {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// If-None-Match 使用弱比较 忽略W/前缀
+func matchETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
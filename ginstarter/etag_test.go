@@ -0,0 +1,70 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newETagEngine(t *testing.T, config ETagConfig) *gin.Engine {
+	return newTestEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{ETagMiddleware(config)},
+		Routers: []Router{&testRouter{
+			info: &RouterInfo{},
+			handlers: func(router *RouterWrapper) {
+				content := func(request *Request) (Response, error) {
+					return RespTextPlain("content"), nil
+				}
+				router.GET("content", content)
+				router.POST("content", content)
+				router.GET("excluded", content)
+				router.GET("custom", func(request *Request) (Response, error) {
+					request.RawGinContext().Header("ETag", `"custom"`)
+					return RespTextPlain("custom"), nil
+				})
+			},
+		}},
+	})
+}
+
+func TestETag(t *testing.T) {
+	engine := newETagEngine(t, ETagConfig{ExcludeRoutes: []string{"/excluded"}})
+
+	recorder := doRequest(engine, http.MethodGet, "/content", nil)
+	etag := recorder.Header().Get("ETag")
+	if recorder.Body.String() != "content" || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("unexpected response %s etag %s", recorder.Body.String(), etag)
+	}
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		recorder = doRequest(engine, http.MethodGet, "/content", nil, "If-None-Match", ifNoneMatch)
+		if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 || recorder.Header().Get("ETag") != etag {
+			t.Fatalf("If-None-Match %s should respond 304, got %d %s", ifNoneMatch, recorder.Code, recorder.Body.String())
+		}
+	}
+	recorder = doRequest(engine, http.MethodGet, "/content", nil, "If-None-Match", `"other"`)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "content" {
+		t.Fatalf("mismatched If-None-Match should respond content, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if recorder = doRequest(engine, http.MethodPost, "/content", nil); recorder.Header().Get("ETag") != "" {
+		t.Fatalf("POST should not get ETag, got %s", recorder.Header().Get("ETag"))
+	}
+	if recorder = doRequest(engine, http.MethodGet, "/excluded", nil); recorder.Header().Get("ETag") != "" {
+		t.Fatalf("excluded route should not get ETag, got %s", recorder.Header().Get("ETag"))
+	}
+	recorder = doRequest(engine, http.MethodGet, "/custom", nil, "If-None-Match", `"custom"`)
+	if recorder.Code != http.StatusNotModified || recorder.Header().Get("ETag") != `"custom"` {
+		t.Fatalf("handler ETag should be kept, got %d %s", recorder.Code, recorder.Header().Get("ETag"))
+	}
+}
+
+func TestETagWeakAndInclude(t *testing.T) {
+	engine := newETagEngine(t, ETagConfig{Weak: true, IncludeRoutes: []string{"/content"}})
+	if etag := doRequest(engine, http.MethodGet, "/content", nil).Header().Get("ETag"); !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("weak ETag expected, got %s", etag)
+	}
+	if etag := doRequest(engine, http.MethodGet, "/excluded", nil).Header().Get("ETag"); etag != "" {
+		t.Fatalf("route outside IncludeRoutes should not get ETag, got %s", etag)
+	}
+}
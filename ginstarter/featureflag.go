@@ -0,0 +1,17 @@
+package ginstarter
+
+// FeatureFlagResolver 特性开关解析器 根据请求(例如用户、租户、灰度标识)返回启用状态
+type FeatureFlagResolver func(request *Request) map[string]bool
+
+// FeatureFlagMiddleware 请求级特性开关中间件 每个请求仅解析一次 保证同一请求内的开关状态一致
+// 处理器中通过 request.FeatureEnabled(name) 判断特性是否启用
+func FeatureFlagMiddleware(resolver FeatureFlagResolver) Middleware {
+	return func(request *Request) {
+		flags := resolver(request)
+		if flags == nil {
+			flags = map[string]bool{}
+		}
+		request.ctx.Set(ginCtxKeyFeatureFlags, flags)
+		request.Next()
+	}
+}
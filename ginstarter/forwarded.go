@@ -0,0 +1,92 @@
+package ginstarter
+
+import (
+	"net"
+	"strings"
+)
+
+// 解析可信代理 支持IP及CIDR
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, v := range proxies {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: v}
+			}
+			if ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// 请求是否来自可信代理 仅可信代理转发的X-Forwarded-*请求头才会被采用
+func (r *Request) isFromTrustedProxy() bool {
+	state := stateOf(r.ctx)
+	if state.config.DisableForwardedByClientIP {
+		return false
+	}
+	// 可信代理网段 nil 表示信任所有代理 与gin默认行为一致
+	if state.trustedProxyNets == nil {
+		return true
+	}
+	ip := net.ParseIP(r.ctx.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, v := range state.trustedProxyNets {
+		if v.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取可信代理转发的请求头中的第一个值
+func (r *Request) forwardedHeader(name string) string {
+	if !r.isFromTrustedProxy() {
+		return ""
+	}
+	value, _, _ := strings.Cut(r.GetHeader(name), ",")
+	return strings.TrimSpace(value)
+}
+
+// Scheme 获取客户端请求使用的协议 http或https
+// 来自可信代理的请求优先使用X-Forwarded-Proto 否则根据是否为TLS连接判断 适用于部署在TLS终止代理之后的场景
+func (r *Request) Scheme() string {
+	proto := strings.ToLower(r.forwardedHeader("X-Forwarded-Proto"))
+	if proto == "http" || proto == "https" {
+		return proto
+	}
+	if r.ctx.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// 获取客户端访问的Host 来自可信代理的请求优先使用X-Forwarded-Host
+func (r *Request) forwardedHost() string {
+	if host := r.forwardedHeader("X-Forwarded-Host"); host != "" {
+		return host
+	}
+	return r.ctx.Request.Host
+}
+
+// BaseURL 获取客户端访问的基础地址 scheme://host 用于构建资源的绝对地址
+func (r *Request) BaseURL() string {
+	return r.Scheme() + "://" + r.forwardedHost()
+}
+
+// FullURL 获取客户端访问的完整地址 scheme://host/path?query 用于构建分页、重定向等绝对链接
+func (r *Request) FullURL() string {
+	return r.BaseURL() + r.ctx.Request.URL.RequestURI()
+}
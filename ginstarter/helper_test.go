@@ -0,0 +1,66 @@
+package ginstarter
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+// 创建独立的gin引擎 不启动服务 不替换当前运行的引擎状态 各用例互不影响
+func newTestEngine(t *testing.T, config GinConfig) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	state, err := newGinEngine(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state.engine
+}
+
+func doRequest(engine *gin.Engine, method, path string, body io.Reader, headers ...string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, path, body)
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// 以函数注册处理器的路由
+type testRouter struct {
+	info     *RouterInfo
+	handlers func(router *RouterWrapper)
+}
+
+func (r *testRouter) Info() *RouterInfo {
+	return r.info
+}
+
+func (r *testRouter) Handlers(router *RouterWrapper) {
+	r.handlers(router)
+}
+
+// 解析Rest响应的状态码
+func restStatusCode(t *testing.T, body []byte) StatusCode {
+	t.Helper()
+	var rest RestRespStruct
+	if err := json.Unmarshal(body, &rest); err != nil || rest.Status == nil {
+		t.Fatalf("unexpected rest response %s", body)
+	}
+	return rest.Status.StatusCode
+}
+
+// 创建仅注册单个路由的独立gin引擎
+func newHandlerEngine(t *testing.T, config GinConfig, method, path string, handler HandlerWrapper) *gin.Engine {
+	t.Helper()
+	config.Routers = append(config.Routers, &testRouter{
+		info: &RouterInfo{},
+		handlers: func(router *RouterWrapper) {
+			router.MATCH([]string{method}, path, handler)
+		},
+	})
+	return newTestEngine(t, config)
+}
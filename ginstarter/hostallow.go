@@ -0,0 +1,50 @@
+package ginstarter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HostAllowlistMiddleware Host请求头白名单中间件 用于防止Host头注入 Host不在白名单中时响应400
+// 支持精确匹配 example.com 及通配匹配 *.example.com (匹配任意子域名 不匹配example.com本身)
+// 匹配时忽略大小写及端口号
+func HostAllowlistMiddleware(hosts ...string) Middleware {
+	exactHosts := make(map[string]struct{}, len(hosts))
+	var wildcardSuffixes []string
+	for _, v := range hosts {
+		v = normalizeHost(v)
+		if suffix, ok := strings.CutPrefix(v, "*."); ok {
+			wildcardSuffixes = append(wildcardSuffixes, "."+suffix)
+		} else if v != "" {
+			exactHosts[v] = struct{}{}
+		}
+	}
+	return func(request *Request) {
+		host := normalizeHost(request.ctx.Request.Host)
+		if host != "" {
+			if _, ok := exactHosts[host]; ok {
+				request.Next()
+				return
+			}
+			for _, suffix := range wildcardSuffixes {
+				if len(host) > len(suffix) && strings.HasSuffix(host, suffix) {
+					request.Next()
+					return
+				}
+			}
+		}
+		request.AbortWithResponse(RespAbortWithHttpStatusCode(http.StatusBadRequest))
+	}
+}
+
+// 去除端口号及末尾的. 并转为小写
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return strings.TrimSuffix(host, ".")
+}
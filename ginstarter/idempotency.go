@@ -0,0 +1,65 @@
+package ginstarter
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIdempotencyHeaderName = "Idempotency-Key"
+	defaultIdempotencyTTL        = time.Hour * 24
+)
+
+var defaultIdempotencyMethods = []string{
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// IdempotencyConfig 幂等配置
+type IdempotencyConfig struct {
+	// 幂等键请求头 默认 Idempotency-Key
+	HeaderName string
+	// 幂等键对应响应的保留时间 默认24小时
+	TTL time.Duration
+	// 参与幂等处理的请求方法 默认 POST PUT PATCH DELETE
+	// GET/HEAD等安全方法天然幂等 通常无需加入
+	Methods []string
+}
+
+// IdempotencyMiddleware 幂等中间件 客户端携带幂等键请求头时 在TTL内相同幂等键的请求直接返回首次请求的响应
+// 仅作用于Methods中的请求方法 其他请求及未携带幂等键的请求直接放行 幂等键按请求方法及路径隔离
+// 重复请求回放首次请求的状态码、响应头及响应体 但不回放Set-Cookie及请求ID、链路等与单次请求绑定的响应头
+func IdempotencyMiddleware(config ...IdempotencyConfig) Middleware {
+	var cfg IdempotencyConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = defaultIdempotencyHeaderName
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultIdempotencyTTL
+	}
+	methods := cfg.Methods
+	if len(methods) == 0 {
+		methods = defaultIdempotencyMethods
+	}
+	allowMethods := make(map[string]struct{}, len(methods))
+	for _, v := range methods {
+		allowMethods[strings.ToUpper(v)] = struct{}{}
+	}
+	return DedupMiddleware(func(request *Request) string {
+		method := request.HttpMethod()
+		if _, ok := allowMethods[method]; !ok {
+			return ""
+		}
+		idempotencyKey := request.GetHeader(cfg.HeaderName)
+		if idempotencyKey == "" {
+			return ""
+		}
+		return method + " " + request.RequestPath() + " " + idempotencyKey
+	}, cfg.TTL)
+}
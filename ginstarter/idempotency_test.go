@@ -0,0 +1,67 @@
+package ginstarter
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestIdempotency(t *testing.T) {
+	var executions atomic.Int32
+	handler := func(request *Request) (Response, error) {
+		return RespTextPlain(strconv.Itoa(int(executions.Add(1)))), nil
+	}
+	engine := newTestEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{IdempotencyMiddleware()},
+		Routers: []Router{&testRouter{
+			info: &RouterInfo{},
+			handlers: func(router *RouterWrapper) {
+				router.POST("orders", handler)
+				router.POST("payments", handler)
+				router.GET("orders", handler)
+			},
+		}},
+	})
+	request := func(method, path string, headers ...string) string {
+		return doRequest(engine, method, path, nil, headers...).Body.String()
+	}
+
+	first := request(http.MethodPost, "/orders", "Idempotency-Key", "k1")
+	if replay := request(http.MethodPost, "/orders", "Idempotency-Key", "k1"); replay != first {
+		t.Fatalf("same key should replay the first response %s, got %s", first, replay)
+	}
+	if other := request(http.MethodPost, "/orders", "Idempotency-Key", "k2"); other == first {
+		t.Fatal("different key should execute the handler")
+	}
+	if other := request(http.MethodPost, "/payments", "Idempotency-Key", "k1"); other == first {
+		t.Fatal("same key on another path should execute the handler")
+	}
+	if request(http.MethodPost, "/orders") == request(http.MethodPost, "/orders") {
+		t.Fatal("requests without key should not be merged")
+	}
+	if request(http.MethodGet, "/orders", "Idempotency-Key", "k1") == request(http.MethodGet, "/orders", "Idempotency-Key", "k1") {
+		t.Fatal("GET is not in the default methods and should not be merged")
+	}
+	if executions.Load() != 7 {
+		t.Fatalf("expected 7 executions, got %d", executions.Load())
+	}
+}
+
+func TestIdempotencyConfig(t *testing.T) {
+	var executions atomic.Int32
+	engine := newHandlerEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{IdempotencyMiddleware(IdempotencyConfig{HeaderName: "X-Request-Key", Methods: []string{"put"}})},
+	}, http.MethodPut, "orders", func(request *Request) (Response, error) {
+		executions.Add(1)
+		return RespTextPlain("ok"), nil
+	})
+	for i := 0; i < 3; i++ {
+		doRequest(engine, http.MethodPut, "/orders", nil, "X-Request-Key", "k1")
+		doRequest(engine, http.MethodPut, "/orders", nil, "Idempotency-Key", "k1")
+	}
+	// X-Request-Key仅执行一次 默认请求头不再作为幂等键
+	if executions.Load() != 4 {
+		t.Fatalf("expected 4 executions, got %d", executions.Load())
+	}
+}
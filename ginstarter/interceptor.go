@@ -3,22 +3,24 @@ package ginstarter
 import (
 	"bytes"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/acexy/golang-toolkit/logger"
 	"github.com/acexy/golang-toolkit/math/conversion"
 	"github.com/acexy/golang-toolkit/util/coll"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"net"
 	"net/http"
 	"strings"
+	"syscall"
 	"time"
 )
 
 var (
 	httpCodeWithStatus          map[int]StatusCode
 	defaultIgnoreHttpStatusCode = []int{
+		http.StatusAccepted,
+		http.StatusPartialContent,
 		http.StatusMultipleChoices,
 		http.StatusMovedPermanently,
 		http.StatusFound,
@@ -27,6 +29,7 @@ var (
 		http.StatusUseProxy,
 		http.StatusTemporaryRedirect,
 		http.StatusPermanentRedirect,
+		http.StatusRequestedRangeNotSatisfiable,
 	}
 
 	panicResolver PanicResolver = func(err error) string {
@@ -60,18 +63,22 @@ var (
 		}
 		body.Status.StatusCode = statusCode
 
-		return NewRespRest().DataBuilder(func() *ResponseData {
-			bodyBytes, _ := ginConfig.ResponseDataStructDecoder.Decode(body)
-			return NewResponseDataWithStatusCode(gin.MIMEJSON, bodyBytes, http.StatusOK)
-		})
+		return NewRespRest().SetDataResponse(body)
 	}
 )
 
 type PanicResolver func(err error) string
+
+// PanicSink panic上报处理器 在捕获panic时调用 不影响响应内容
+// panicContext 为处理链中通过 request.SetPanicContext 附加的业务数据
+type PanicSink func(request *Request, err error, panicContext map[string]any)
 type BadHttpCodeResolver func(httpStatusCode int, errMsg string) Response
 
+// AbortedRequestResolver 请求到达业务处理器时已被中断的处理器 返回非nil的Response将作为响应输出
+type AbortedRequestResolver func(request *Request) Response
+
 func init() {
-	httpCodeWithStatus = make(map[int]StatusCode, 7)
+	httpCodeWithStatus = make(map[int]StatusCode, 10)
 	httpCodeWithStatus[http.StatusBadRequest] = StatusCodeBadRequestParameters
 	httpCodeWithStatus[http.StatusForbidden] = StatusCodeForbidden
 	httpCodeWithStatus[http.StatusNotFound] = StatusCodeNotFound
@@ -79,18 +86,21 @@ func init() {
 	httpCodeWithStatus[http.StatusUnsupportedMediaType] = StatusCodeMediaTypeNotAllowed
 	httpCodeWithStatus[http.StatusRequestEntityTooLarge] = StatusCodeUploadLimitExceeded
 	httpCodeWithStatus[http.StatusUnauthorized] = StatusCodeUnauthorized
+	httpCodeWithStatus[http.StatusTooManyRequests] = StatusCodeExceededLimit
+	httpCodeWithStatus[http.StatusServiceUnavailable] = StatusCodeServiceUnavailable
+	httpCodeWithStatus[http.StatusPreconditionFailed] = StatusCodePreconditionFailed
 }
 
-func isIgnoreHttpStatusCode(httpCode int) bool {
-	if !ginConfig.DisableDefaultIgnoreHttpCode {
+func isIgnoreHttpStatusCode(config *GinConfig, httpCode int) bool {
+	if !config.DisableDefaultIgnoreHttpCode {
 		for _, v := range defaultIgnoreHttpStatusCode {
 			if httpCode == v {
 				return true
 			}
 		}
 	}
-	if len(ginConfig.IgnoreHttpCode) > 0 {
-		for _, v := range ginConfig.IgnoreHttpCode {
+	if len(config.IgnoreHttpCode) > 0 {
+		for _, v := range config.IgnoreHttpCode {
 			if httpCode == v {
 				return true
 			}
@@ -100,6 +110,13 @@ func isIgnoreHttpStatusCode(httpCode int) bool {
 }
 
 func panicToError(panicError any) (statusCode int, err error, internalError bool) {
+	statusCode, err, internalError = resolvePanic(panicError)
+	logger.Logrus().Errorf("panic: %v", err)
+	return
+}
+
+// 将panic转换为响应状态码及错误 statusCode为0时由调用方决定(通常为500)
+func resolvePanic(panicError any) (statusCode int, err error, internalError bool) {
 	switch t := panicError.(type) {
 	case string:
 		err = errors.New(t)
@@ -108,45 +125,77 @@ func panicToError(panicError any) (statusCode int, err error, internalError bool
 	default:
 		// 内部特殊错误
 		if v, ok := t.(*internalPanic); ok {
-			rawError := v.rawError
+			var bindStatusCode int
 			statusCode = v.statusCode
-			if validationErrs, ok := rawError.(validator.ValidationErrors); ok {
-				internalError = true
-				err = errors.New(friendlyValidatorMessage(validationErrs))
-			} else if jsonErr, ok := rawError.(*json.UnmarshalTypeError); ok {
-				err = errors.New(jsonErr.Field + " type mismatch")
-			} else if _, ok := rawError.(*json.SyntaxError); ok {
-				err = errors.New("bad json payload")
-			} else {
-				err = rawError
+			err, bindStatusCode, internalError = parseBindError(v.rawError)
+			if bindStatusCode != 0 {
+				statusCode = bindStatusCode
 			}
 		} else {
 			err = fmt.Errorf("%v", t)
 		}
 	}
-	logger.Logrus().Errorf("panic: %v", err)
 	return
 }
 
+// 判断是否为客户端断开连接导致的错误
+func isBrokenPipeError(err error) bool {
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) {
+		message := strings.ToLower(opError.Err.Error())
+		return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+	}
+	return false
+}
+
+// PlainTextPanicResponse 隐藏异常细节时以纯文本响应500错误 可用于GinConfig.HidePanicResponse
+func PlainTextPanicResponse() Response {
+	return RespTextPlain(statusMessageException, http.StatusInternalServerError)
+}
+
 // recoverHandler 全局Panic处理中间件
 func recoverHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		config := configOf(ctx)
 		// panic异常处理
 		defer func() {
 			if panicError := recover(); panicError != nil {
 
+				// 响应已部分输出后主动中断(例如反向代理复制上游响应失败) 交由net/http直接关闭连接 避免客户端收到看似完整的响应
+				if err, ok := panicError.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					logger.Logrus().Debugln("response aborted path:", ctx.Request.URL)
+					ctx.Abort()
+					panic(err)
+				}
+
+				// 客户端断开连接导致的写入异常 不属于业务异常 无需响应
+				if err, ok := panicError.(error); ok && isBrokenPipeError(err) {
+					logger.Logrus().Debugln("client disconnected path:", ctx.Request.URL, "error:", err)
+					ctx.Abort()
+					return
+				}
+
 				var errMsg string
+				var hiddenPanic bool
 				// 将panic异常进行转换
 				status, err, internalError := panicToError(panicError)
-				if ginConfig.HidePanicErrorDetails { // 禁用异常信息显示
+				if config.PanicSink != nil {
+					request := &Request{ctx: ctx}
+					config.PanicSink(request, err, request.PanicContext())
+				}
+				if config.HidePanicErrorDetails { // 禁用异常信息显示
 					if !internalError {
+						hiddenPanic = true
 						errMsg = ""
 						status = 500
 					} else {
 						errMsg = err.Error()
 					}
 				} else {
-					errMsg = ginConfig.PanicResolver(err)
+					errMsg = config.PanicResolver(err)
 				}
 
 				if status != 0 {
@@ -158,14 +207,19 @@ func recoverHandler() gin.HandlerFunc {
 				var rewriter *responseRewriter
 				// 如果使用了可覆写中间件
 				if w, ok := writer.(*responseRewriter); ok {
+					if w.passthrough { // 流式响应已开始输出 无法再重写响应
+						return
+					}
 					rewriter = w
 					statusCode = w.statusCode
 				} else {
 					statusCode = ctx.Writer.Status()
 				}
 				var response Response
-				if !ginConfig.DisableBadHttpCodeResolver {
-					response = ginConfig.BadHttpCodeResolver(statusCode, errMsg)
+				if hiddenPanic && config.HidePanicResponse != nil {
+					response = config.HidePanicResponse()
+				} else if !config.DisableBadHttpCodeResolver {
+					response = config.BadHttpCodeResolver(statusCode, errMsg)
 				} else {
 					response = RespTextPlain(errMsg, statusCode)
 				}
@@ -179,10 +233,13 @@ func recoverHandler() gin.HandlerFunc {
 
 		ctx.Next()
 		// 异常响应码处理
-		if !ginConfig.DisableBadHttpCodeResolver {
+		if !config.DisableBadHttpCodeResolver {
 			var statusCode int
 			var rewriter *responseRewriter
 			if v, ok := ctx.Writer.(*responseRewriter); ok {
+				if v.passthrough {
+					return
+				}
 				rewriter = v
 				if v.statusCode != 0 && v.statusCode != http.StatusOK {
 					statusCode = v.statusCode
@@ -193,11 +250,11 @@ func recoverHandler() gin.HandlerFunc {
 				statusCode = ctx.Writer.Status()
 			}
 			if statusCode != http.StatusOK {
-				if isIgnoreHttpStatusCode(statusCode) {
+				if isIgnoreHttpStatusCode(config, statusCode) || ctx.GetBool(ginCtxKeyKeepHttpStatus) {
 					return
 				}
 				logger.Logrus().Warningln("Bad response path:", ctx.Request.URL, "status code:", statusCode)
-				response := ginConfig.BadHttpCodeResolver(statusCode, "")
+				response := config.BadHttpCodeResolver(statusCode, "")
 				httpResponse(ctx, response)
 				if rewriter != nil {
 					rewriter.ResponseWriter.WriteHeader(rewriter.statusCode)
@@ -217,6 +274,9 @@ func responseRewriteHandler() gin.HandlerFunc {
 		}
 		ctx.Writer = writer
 		ctx.Next()
+		if writer.passthrough { // 流式响应已直接输出
+			return
+		}
 		if writer.statusCode == 0 { // 未设置自定义状态码
 			writer.statusCode = writer.ResponseWriter.Status()
 		}
@@ -248,6 +308,7 @@ func BasicAuthInterceptor(account *BasicAuthAccount, match ...func(request *Requ
 		if request.GetHeader("Authorization") != enc {
 			return RespAbortWithHttpStatusCode(http.StatusUnauthorized), false
 		}
+		SetAuthIdentity(request, account.Username)
 		return nil, true
 	}
 }
@@ -2,20 +2,86 @@ package ginstarter
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"github.com/acexy/golang-toolkit/logger"
-	"github.com/acexy/golang-toolkit/util/net"
+	netutil "github.com/acexy/golang-toolkit/util/net"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-acexy/starter-parent/parent"
 	"github.com/sirupsen/logrus"
+	"io"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
+const (
+	defaultShutdownTimeout     = time.Second * 30
+	defaultListenRetryInterval = time.Millisecond * 500
+)
+
 var once sync.Once
 var server *http.Server
-var ginEngine *gin.Engine
-var ginConfig *GinConfig
+var engineHandler = &reloadableHandler{}
+
+// 引擎运行状态 包含配置及根据配置创建的gin引擎、访问日志、调试记录等 启动及热重载时整体原子替换
+// 请求处理中通过stateOf读取该请求所属引擎的状态 热重载后旧引擎中的请求仍使用旧状态
+type engineState struct {
+	config           *GinConfig
+	engine           *gin.Engine
+	accessLogger     *logrus.Logger
+	debugRing        *debugRecordRing
+	routeSchemas     *routeSchemaRecorder
+	trustedProxyNets []*net.IPNet
+	operationNames   map[string]string
+
+	// 仅在注册路由期间使用
+	autoHeadRoutes    []*autoHeadRoute
+	autoOptionsRoutes []*autoOptionsRoute
+}
+
+var currentState atomic.Pointer[engineState]
+
+// 当前生效的配置 未加载配置时返回nil
+func currentConfig() *GinConfig {
+	if state := currentState.Load(); state != nil {
+		return state.config
+	}
+	return nil
+}
+
+// 请求所属引擎的运行状态 不在引擎处理链中时返回当前状态
+func stateOf(ctx *gin.Context) *engineState {
+	if ctx != nil {
+		if v, ok := ctx.Get(ginCtxKeyEngineState); ok {
+			return v.(*engineState)
+		}
+	}
+	return currentState.Load()
+}
+
+// 请求所属引擎的配置
+func configOf(ctx *gin.Context) *GinConfig {
+	return stateOf(ctx).config
+}
+
+// 可替换gin引擎的http处理器 用于热重载时原子替换正在运行服务的引擎
+type reloadableHandler struct {
+}
+
+func (h *reloadableHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	state := currentState.Load()
+	if timeout := state.config.RequestContextTimeout; timeout > 0 {
+		ctx, cancel := context.WithTimeout(request.Context(), timeout)
+		defer cancel()
+		request = request.WithContext(ctx)
+	}
+	state.engine.ServeHTTP(writer, request)
+}
 
 type GinConfig struct {
 
@@ -28,13 +94,53 @@ type GinConfig struct {
 	// * 注册服务监听地址 :8080 (默认)
 	ListenAddress string // ip:port
 
+	// 自定义监听器 设置后将通过该监听器提供服务 忽略ListenAddress
+	// 适用于systemd socket activation、PROXY protocol等需要自行创建监听器的场景
+	Listener net.Listener
+
+	// 启用PROXY protocol(v1/v2) 适用于部署在AWS NLB等L4负载均衡之后 使RemoteAddr反映真实客户端地址
+	// 启用后ClientIP将基于真实客户端地址计算 如果负载均衡不会设置X-Forwarded-For等请求头
+	// 建议同时设置DisableForwardedByClientIP或通过TrustedProxies限制可信代理 防止客户端伪造转发请求头
+	EnableProxyProtocol bool
+
+	// HTTPS证书及私钥文件路径 需同时设置 设置后以TLS方式在ListenAddress上提供服务
+	TLSCertFile string
+	TLSKeyFile  string
+	// 自定义TLS配置 例如最低TLS版本、加密套件 已包含Certificates或GetCertificate时可不设置证书文件
+	TLSConfig *tls.Config
+
+	// 监听地址被占用时的重试次数 适用于滚动重启时旧进程尚未释放端口的场景 默认0 不重试
+	ListenRetryTimes int
+	// 监听重试的初始间隔 每次重试后加倍 默认500毫秒
+	ListenRetryInterval time.Duration
+
+	// 停止服务时等待请求处理完成的最大时间 默认30秒
+	ShutdownTimeout time.Duration
+
+	// 请求上下文超时时间 在进入gin引擎之前为每个请求的context设置截止时间 所有中间件及处理器均继承该取消信号
+	// 仅作用于request.Context() 处理器需自行响应ctx.Done() 0 表示不设置
+	RequestContextTimeout time.Duration
+
+	// 停止服务时的清理回调 在服务停止接收新连接并等待处理中的请求完成后按注册顺序执行 例如刷新缓冲、关闭连接池
+	// ctx 为停止服务的超时上下文 回调发生panic时记录错误日志并继续执行后续回调
+	OnShutdown []func(ctx context.Context)
+
 	// 默认情况系统会将捕获的异常详细发给PanicResolver处理，如果不想将细节暴露向外
 	// 方案 1. 启用隐藏异常细节功能，系统将在触发panic重要错误时不再调用PanicResolver处理，并统一响应500错误
 	// 方案 2. 如果不想禁用异常时调用PanicResolver, 可以在初始化时手动设置自定义PanicResolver处理器
 	// * panic 将被分为框架内部错误和框架未知错误 框架内部错误是非敏感错误，不受该参数控制，每次都会触发PanicResolver，例如验证框架错误
 	HidePanicErrorDetails bool
+	// 启用隐藏异常细节时 非框架内部错误的通用异常响应 不设置则使用BadHttpCodeResolver响应Rest结构(禁用时为纯文本)
+	// 可使用内置的PlainTextPanicResponse响应纯文本 或自定义任意格式的Response
+	HidePanicResponse func() Response
 	// 全局异常响应处理器 如果不指定则使用默认方式
 	PanicResolver PanicResolver
+	// panic上报处理器 捕获panic时调用 可获取请求中附加的panic上下文数据 用于上报错误
+	PanicSink PanicSink
+
+	// 请求到达业务处理器时已被中断的处理器 不设置则仅记录Warning日志
+	// 可用于输出统一的中断响应 或自行降低日志级别
+	AbortedRequestResolver AbortedRequestResolver
 
 	// 禁用异常http响应码Resolver
 	DisableBadHttpCodeResolver bool
@@ -45,22 +151,100 @@ type GinConfig struct {
 	// 启用异常http响应码Resolver 如果不指定则使用默认方式
 	BadHttpCodeResolver BadHttpCodeResolver
 
+	// 自动启用请求ID 沿用请求头X-Request-Id或生成新的请求ID并写入响应头 先于所有自定义中间件执行
+	// 需要自定义请求头或生成策略时 不启用该配置并显式注册RequestIDMiddleware
+	AutoRequestID bool
+
+	// 运行时可调整配置(日志级别、维护模式、全局限流)的初始值 启动后通过UpdateRuntimeConfig并发安全地修改
+	// Reload时不会使用新配置中的该值覆盖当前的运行时配置
+	Runtime RuntimeConfig
+
+	// 启用全局响应压缩 根据请求头Accept-Encoding协商gzip或deflate(优先gzip) 流式响应不压缩
+	// 仅需对部分路由压缩时 不启用该配置 通过RouterInfo.Middlewares或router.Use(...)注册CompressionMiddleware
+	EnableCompression bool
+	// 启用全局响应压缩时的最小压缩字节数 默认1024
+	CompressionMinLength int
+	// 启用全局响应压缩时允许压缩的ContentType 支持 text/* 形式的通配 默认为常见的文本类型 不应包含图片等已压缩的类型
+	CompressionContentTypes []string
+
+	// 同时活跃的流式连接(RespStream/RespSSE及通过request.AcquireStream占用的websocket等长连接)数上限
+	// 超过时新的流式请求响应503 0 表示不限制
+	MaxStreamConnections int
+
+	// 自定义全局中间件 按照顺序执行 先于全局拦截器执行 可同时处理业务路由执行前后的逻辑
+	GlobalMiddlewares []Middleware
+	// 自定义带优先级的全局中间件 与GlobalMiddlewares合并后按优先级排序执行 适用于多个模块分别组装中间件的场景
+	GlobalPriorityMiddlewares []PriorityMiddleware
+
 	// 自定义全局拦截器 按照顺序执行 作用于 业务路由执行前
 	GlobalPreInterceptors []PreInterceptor
 
 	// 自定义全局拦截器 按照顺序执行 作用于 业务路由执行后
 	GlobalPostInterceptors []PostInterceptor
 
+	// ResponseData中响应头及Cookie各自的最大写出数量 超出部分将被截断并记录警告日志 防止循环等缺陷导致响应头无限增长
+	// 默认100 小于0表示不限制
+	MaxResponseHeaders int
+	// ResponseData中响应头及Cookie各自写出的最大字节数(按名称与值估算) 超出部分将被截断并记录警告日志 避免超出客户端或代理的响应头大小限制
+	// 默认64KB 小于0表示不限制
+	MaxResponseHeaderBytes int
+
+	// 响应数据为空且状态码为200时 响应默认的Rest成功结构 不设置则仅响应状态码及已设置的响应头/Cookie
+	EmptyBodyRestResponse bool
+
+	// BindBodyJson绑定时拒绝body中结构体未定义的字段 响应参数错误并提示该字段名 也可通过BindBodyJsonStrict为单个接口启用
+	DisallowUnknownJsonFields bool
+
+	// 参数绑定(Bind*)校验失败时的钩子 接收路由模板及校验失败的字段/标签 不包含请求中的参数值 可用于统计分析
+	ValidationFailureHook ValidationFailureHook
+
+	// 绑定请求body(BindBodyJson/BindBodyForm)时允许读取的最大字节数 超过时响应413 0 表示不限制
+	// 独立于路由的MaxBodyBytes 仅作用于绑定过程 防止绑定超大body导致内存耗尽
+	MaxBindBodyBytes int64
+
+	// Rest响应成功时的状态码 默认200 使用指针以支持设置为0
+	SuccessStatusCode *StatusCode
+	// Rest响应成功时的状态描述 默认 Request Success
+	SuccessStatusMessage StatusMessage
+
 	// 响应数据的结构体解码器 默认为JSON方式解码
-	// 在使用NewRespRest响应结构体数据时解码为[]byte数据的解码器
+	// 在使用NewRespRest响应结构体数据时解码为[]byte数据的解码器 RespJson同样使用该解码器 以保证Rest响应与普通Json响应使用同一编码实现
 	// 如果自实现Response接口将不使用解码器
 	ResponseDataStructDecoder ResponseDataStructDecoder
+	// 默认JSON解码器不转义字符串中的 < > & 适用于响应URL或HTML片段的场景 自定义解码器时不生效
+	DisableJsonEscapeHTML bool
+	// 默认JSON解码器的字段命名策略 对未设置json标签名的结构体字段按策略转换字段名 显式设置的json标签名保持不变
+	// 例如 JsonNamingSnakeCase 将 UserName 响应为 user_name 自定义解码器时不生效
+	JsonNamingStrategy JsonNamingStrategy
+	// 按ContentType注册的响应编码器 request.Respond根据Accept请求头从中协商响应格式 不设置则支持Json及Xml
+	ResponseEncoderRegistry *ResponseEncoderRegistry
 
 	// 尝试启用TraceId响应
 	// https://github.com/acexy/golang-toolkit/blob/main/sys/threadlocal.go
 	// 如果工作环境开启EnableLocalTraceId ，将自动响应TranceId头
 	EnableGoroutineTraceIdResponse bool
 
+	// 调试请求环形缓冲 记录最近N个请求的方法、路径、状态码、耗时及截断的请求/响应body
+	// 仅在DebugModule开启时生效 0 表示不启用 通过DebugRecentRequests获取记录
+	DebugRingSize int
+	// 调试请求环形缓冲中请求/响应body的最大记录字节数 默认1024
+	DebugRingBodyLimit int
+	// 调试请求环形缓冲的内置查看路径 例如 /debug/requests 不设置则不注册
+	DebugRingPath string
+	// 记录各路由请求绑定的目标类型(BindBodyJson等)及响应数据类型(RespRestSuccess RespJson等) 辅助生成接口文档
+	// 仅在DebugModule开启时生效 通过DebugRouteSchemas获取记录
+	DebugRecordSchema bool
+
+	// 文件响应(StaticFS/RespFileFS)支持的单个Range请求最大区间数 多个区间将以206 multipart/byteranges响应
+	// 超过该值时忽略Range请求头并响应完整内容 默认16 小于0表示不限制
+	MaxByteRanges int
+
+	// 访问日志输出目标 设置后gin的默认输出(gin.DefaultWriter)及AccessLogMiddleware的日志将写入该Writer 例如单独的访问日志文件
+	// 不设置则使用全局logrus输出
+	AccessLogWriter io.Writer
+	// gin错误日志输出目标(gin.DefaultErrorWriter) 不设置则使用全局logrus以Error级别输出
+	ErrorLogWriter io.Writer
+
 	// ========== gin config
 	DebugModule        bool
 	MaxMultipartMemory int64
@@ -68,8 +252,27 @@ type GinConfig struct {
 	// 关闭包裹405错误展示，使用404代替
 	DisableMethodNotAllowedError bool
 
+	// 移除请求路径中多余的斜杠后再匹配路由 例如 /users//123 按 /users/123 处理
+	RemoveExtraSlash bool
+
+	// 为所有注册的GET路由自动注册HEAD路由 响应与GET相同的响应头但不包含响应体
+	// 如果已显式注册了同路径的HEAD路由则不会覆盖
+	AutoHead bool
+
+	// 为通过RouterWrapper.MATCH注册的路由自动注册OPTIONS路由 响应204及Allow响应头 列出该路径注册的所有请求方法
+	// 如果已显式注册了同路径的OPTIONS路由则不会覆盖 跨域预检请求仍由CorsMiddleware设置跨域响应头
+	AutoMatchOptions bool
+
 	// 禁用尝试获取转发真实IP
 	DisableForwardedByClientIP bool
+
+	// 可信代理 IP或CIDR 设置后将同时作用于ClientIP及X-Forwarded-Proto等转发请求头的信任判断
+	// 不设置时与gin默认行为一致 信任所有代理 设置为空切片表示不信任任何代理
+	TrustedProxies []string
+
+	// 可信平台的真实IP请求头 例如 gin.PlatformCloudflare (CF-Connecting-IP) gin.PlatformGoogleAppEngine (X-Appengine-Remote-Addr)
+	// 设置后ClientIP优先从该请求头获取 适用于部署在固定平台之后的场景
+	TrustedPlatform string
 }
 
 type GinStarter struct {
@@ -87,12 +290,12 @@ func (g *GinStarter) getConfig() *GinConfig {
 	once.Do(func() {
 		if g.LazyConfig != nil {
 			config := g.LazyConfig()
-			ginConfig = &config
+			currentState.Store(&engineState{config: &config})
 		} else {
-			ginConfig = &g.Config
+			currentState.Store(&engineState{config: &g.Config})
 		}
 	})
-	return ginConfig
+	return currentConfig()
 }
 
 func (g *GinStarter) Setting() *parent.Setting {
@@ -100,11 +303,15 @@ func (g *GinStarter) Setting() *parent.Setting {
 		return g.GinSetting
 	}
 	config := g.getConfig()
+	shutdownTimeout := config.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
 	return parent.NewSetting(
 		"Gin-Starter",
 		0,
 		false,
-		time.Second*30,
+		shutdownTimeout,
 		func(instance interface{}) {
 			if config.InitFunc != nil {
 				config.InitFunc(instance.(*gin.Engine))
@@ -112,9 +319,8 @@ func (g *GinStarter) Setting() *parent.Setting {
 		})
 }
 
-func (g *GinStarter) Start() (interface{}, error) {
-	var err error
-	config := g.getConfig()
+// 设置gin的运行模式及默认输出 均为gin的包级变量 仅在启动时设置 Reload时不修改
+func setupGinGlobals(config *GinConfig) {
 	if config.DebugModule {
 		gin.SetMode(gin.DebugMode)
 	} else {
@@ -122,37 +328,110 @@ func (g *GinStarter) Start() (interface{}, error) {
 	}
 	gin.DefaultWriter = &logrusLogger{log: logger.Logrus(), level: logrus.DebugLevel}
 	gin.DefaultErrorWriter = &logrusLogger{log: logger.Logrus(), level: logrus.ErrorLevel}
-	ginEngine = gin.New()
+	if config.AccessLogWriter != nil {
+		gin.DefaultWriter = config.AccessLogWriter
+	}
+	if config.ErrorLogWriter != nil {
+		gin.DefaultErrorWriter = config.ErrorLogWriter
+	}
+}
+
+// 根据配置创建gin引擎 注册全局中间件及路由 返回的状态尚未生效 由调用方原子替换
+// 配置非法(例如TrustedProxies格式错误)时返回错误
+func newGinEngine(config *GinConfig) (*engineState, error) {
+	state := &engineState{config: config, operationNames: make(map[string]string)}
+	if config.AccessLogWriter != nil {
+		state.accessLogger = newWriterLogger(config.AccessLogWriter)
+	}
+	engine := gin.New()
+	state.engine = engine
 	registerValidators()
-	ginEngine.Use(recoverHandler())
+
+	// 记录请求开始时间 作为框架与处理器统一的计时基准 请求处理完成后归还对象池中获取的响应
+	engine.Use(func(ctx *gin.Context) {
+		ctx.Set(ginCtxKeyEngineState, state)
+		ctx.Set(ginCtxKeyStartTime, time.Now())
+		ctx.Next()
+		releasePooledResp(ctx)
+	})
+
+	// 全局响应压缩 位于Panic处理及状态码重写之外 使重写后的最终响应同样被压缩
+	if config.EnableCompression {
+		engine.Use(CompressionMiddleware(CompressionConfig{
+			MinLength:    config.CompressionMinLength,
+			ContentTypes: config.CompressionContentTypes,
+		}).handlerFunc())
+	}
+
+	if config.DebugModule && config.DebugRingSize > 0 {
+		state.debugRing = newDebugRecordRing(config.DebugRingSize)
+		bodyLimit := config.DebugRingBodyLimit
+		if bodyLimit <= 0 {
+			bodyLimit = defaultDebugRingBodyLimit
+		}
+		engine.Use(debugRingHandler(state.debugRing, bodyLimit, config.DebugRingPath))
+	}
+
+	if config.DebugModule && config.DebugRecordSchema {
+		state.routeSchemas = newRouteSchemaRecorder()
+	}
+
+	engine.Use(recoverHandler())
 
 	if config.PanicResolver == nil {
 		config.PanicResolver = panicResolver
 	}
 
 	if config.MaxMultipartMemory > 0 {
-		ginEngine.MaxMultipartMemory = config.MaxMultipartMemory
+		engine.MaxMultipartMemory = config.MaxMultipartMemory
+	}
+
+	engine.ForwardedByClientIP = !config.DisableForwardedByClientIP
+
+	if config.TrustedProxies != nil {
+		if err := engine.SetTrustedProxies(config.TrustedProxies); err != nil {
+			return nil, fmt.Errorf("invalid TrustedProxies: %w", err)
+		}
+		nets, err := parseTrustedProxies(config.TrustedProxies)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TrustedProxies: %w", err)
+		}
+		state.trustedProxyNets = nets
 	}
 
-	ginEngine.ForwardedByClientIP = !config.DisableForwardedByClientIP
+	if config.TrustedPlatform != "" {
+		engine.TrustedPlatform = config.TrustedPlatform
+	}
 
 	if !config.DisableMethodNotAllowedError {
-		ginEngine.HandleMethodNotAllowed = true
+		engine.HandleMethodNotAllowed = true
 	}
 
+	engine.RemoveExtraSlash = config.RemoveExtraSlash
+
 	if !config.DisableBadHttpCodeResolver {
-		ginEngine.Use(responseRewriteHandler())
+		engine.Use(responseRewriteHandler())
 		if config.BadHttpCodeResolver == nil {
 			config.BadHttpCodeResolver = badHttpCodeResolver
 		}
 	}
 
 	if config.ResponseDataStructDecoder == nil {
-		config.ResponseDataStructDecoder = responseJsonDataStructDecoder{}
+		config.ResponseDataStructDecoder = newResponseJsonDataStructDecoder(config.DisableJsonEscapeHTML, config.JsonNamingStrategy)
+	}
+
+	if config.AutoRequestID {
+		engine.Use(RequestIDMiddleware().handlerFunc())
+	}
+
+	engine.Use(runtimeConfigHandler())
+
+	if len(config.GlobalMiddlewares) > 0 || len(config.GlobalPriorityMiddlewares) > 0 {
+		useMiddlewares(engine, sortMiddlewares(config.GlobalMiddlewares, config.GlobalPriorityMiddlewares))
 	}
 
 	if len(config.GlobalPreInterceptors) > 0 {
-		ginEngine.Use(func(ctx *gin.Context) {
+		engine.Use(func(ctx *gin.Context) {
 			for i := range config.GlobalPreInterceptors {
 				interceptor := config.GlobalPreInterceptors[i]
 				if interceptor != nil {
@@ -169,7 +448,7 @@ func (g *GinStarter) Start() (interface{}, error) {
 	}
 
 	if len(config.GlobalPostInterceptors) > 0 {
-		ginEngine.Use(func(ctx *gin.Context) {
+		engine.Use(func(ctx *gin.Context) {
 			ctx.Next()
 			for i := range config.GlobalPostInterceptors {
 				interceptor := config.GlobalPostInterceptors[i]
@@ -194,22 +473,73 @@ func (g *GinStarter) Start() (interface{}, error) {
 	}
 
 	if len(config.Routers) > 0 {
-		registerRouter(ginEngine, config.Routers)
+		registerRouter(state, config.Routers)
+	}
+
+	if ring := state.debugRing; ring != nil && config.DebugRingPath != "" {
+		engine.GET(config.DebugRingPath, func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, ring.snapshot())
+		})
 	}
+	return state, nil
+}
 
-	if config.ListenAddress == "" {
+func (g *GinStarter) Start() (interface{}, error) {
+	var err error
+	config := g.getConfig()
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, errors.New("TLSCertFile and TLSKeyFile must be set together")
+	}
+	setupGinGlobals(config)
+	state, err := newGinEngine(config)
+	if err != nil {
+		return nil, err
+	}
+	currentState.Store(state)
+	ginEngine := state.engine
+	initialRuntime := config.Runtime
+	if err = storeRuntimeConfig(&initialRuntime); err != nil {
+		return ginEngine, err
+	}
+
+	if config.Listener != nil {
+		config.ListenAddress = config.Listener.Addr().String()
+	} else if config.ListenAddress == "" {
 		config.ListenAddress = ":8080"
 	}
 
 	server = &http.Server{
 		Addr:    config.ListenAddress,
-		Handler: ginEngine,
+		Handler: engineHandler,
+	}
+	enableTLS := config.TLSCertFile != "" || config.TLSConfig != nil
+	if enableTLS {
+		if server.TLSConfig, err = newTLSConfig(config); err != nil {
+			return ginEngine, err
+		}
+	}
+
+	listener := config.Listener
+	if listener == nil {
+		listener, err = listen(config)
+		if err != nil {
+			return ginEngine, err
+		}
+	}
+	if config.EnableProxyProtocol {
+		listener = newProxyProtocolListener(listener)
 	}
 
-	errChn := make(chan error)
+	errChn := make(chan error, 1)
 	go func() {
-		if err = server.ListenAndServe(); err != nil {
-			errChn <- err
+		var serveErr error
+		if enableTLS {
+			serveErr = server.ServeTLS(listener, "", "")
+		} else {
+			serveErr = server.Serve(listener)
+		}
+		if serveErr != nil {
+			errChn <- serveErr
 		}
 	}()
 
@@ -217,10 +547,105 @@ func (g *GinStarter) Start() (interface{}, error) {
 	case <-time.After(time.Second):
 		return ginEngine, nil
 	case err = <-errChn:
+		_ = listener.Close()
 		return ginEngine, err
 	}
 }
 
+// 加载证书文件并合并自定义TLS配置
+func newTLSConfig(config *GinConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if config.TLSConfig != nil {
+		tlsConfig = config.TLSConfig.Clone()
+	}
+	if config.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+	if len(tlsConfig.Certificates) == 0 && tlsConfig.GetCertificate == nil && tlsConfig.GetConfigForClient == nil {
+		return nil, errors.New("tls certificate is not configured")
+	}
+	return tlsConfig, nil
+}
+
+// 监听ListenAddress 地址被占用时按ListenRetryTimes指数退避重试
+func listen(config *GinConfig) (net.Listener, error) {
+	interval := config.ListenRetryInterval
+	if interval <= 0 {
+		interval = defaultListenRetryInterval
+	}
+	for i := 0; ; i++ {
+		listener, err := net.Listen("tcp", config.ListenAddress)
+		if err == nil {
+			return listener, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			return nil, err
+		}
+		if i >= config.ListenRetryTimes {
+			return nil, fmt.Errorf("address %s already in use: %w", config.ListenAddress, err)
+		}
+		logger.Logrus().Warningln("address", config.ListenAddress, "already in use, retry after", interval)
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+// 热重载互斥 保证同一时间只有一个Reload在创建及替换引擎
+var reloadMu sync.Mutex
+
+// Reload 热重载 使用新配置重新创建gin引擎 并原子替换正在运行服务的处理器 不中断监听及已建立的连接
+// 新引擎及其配置、访问日志、调试记录等状态创建完成后整体替换 替换前已进入旧引擎的请求继续使用旧引擎的状态处理完成
+// 需要在运行期间频繁调整的配置应使用RuntimeConfig 通过UpdateRuntimeConfig并发安全地修改
+// 监听相关配置(ListenAddress、Listener、EnableProxyProtocol、TLS、ListenRetry、ShutdownTimeout)
+// 及gin包级配置(DebugModule、AccessLogWriter、ErrorLogWriter)不支持热重载 将沿用原配置
+// 新引擎创建失败(例如路由冲突)时返回错误 继续使用原引擎及原配置提供服务
+func (g *GinStarter) Reload(newConfig GinConfig) (err error) {
+	if server == nil {
+		return errors.New("gin server is not started")
+	}
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	oldConfig := currentConfig()
+	newConfig.ListenAddress = oldConfig.ListenAddress
+	newConfig.Listener = oldConfig.Listener
+	newConfig.EnableProxyProtocol = oldConfig.EnableProxyProtocol
+	newConfig.TLSCertFile = oldConfig.TLSCertFile
+	newConfig.TLSKeyFile = oldConfig.TLSKeyFile
+	newConfig.TLSConfig = oldConfig.TLSConfig
+	newConfig.ListenRetryTimes = oldConfig.ListenRetryTimes
+	newConfig.ListenRetryInterval = oldConfig.ListenRetryInterval
+	newConfig.ShutdownTimeout = oldConfig.ShutdownTimeout
+	newConfig.DebugModule = oldConfig.DebugModule
+	newConfig.AccessLogWriter = oldConfig.AccessLogWriter
+	newConfig.ErrorLogWriter = oldConfig.ErrorLogWriter
+	// 路由冲突等由gin注册路由时触发panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reload gin engine failed: %v", r)
+		}
+	}()
+	state, err := newGinEngine(&newConfig)
+	if err != nil {
+		return fmt.Errorf("reload gin engine failed: %w", err)
+	}
+	if newConfig.InitFunc != nil {
+		newConfig.InitFunc(state.engine)
+	}
+	// 与UpdateRuntimeConfig互斥 使新的访问日志沿用当前的运行时日志级别
+	runtimeConfigMu.Lock()
+	if state.accessLogger != nil {
+		state.accessLogger.SetLevel(logger.Logrus().GetLevel())
+	}
+	currentState.Store(state)
+	runtimeConfigMu.Unlock()
+	logger.Logrus().Infoln("gin engine reloaded")
+	return nil
+}
+
 func (g *GinStarter) Stop(maxWaitTime time.Duration) (gracefully, stopped bool, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), maxWaitTime)
 	defer cancel()
@@ -229,11 +654,31 @@ func (g *GinStarter) Stop(maxWaitTime time.Duration) (gracefully, stopped bool,
 	} else {
 		gracefully = true
 	}
-	stopped = !net.Telnet(g.getConfig().ListenAddress, time.Second)
+	runShutdownCallbacks(ctx, g.getConfig().OnShutdown)
+	stopped = !netutil.Telnet(g.getConfig().ListenAddress, time.Second)
 	return
 }
 
+func runShutdownCallbacks(ctx context.Context, callbacks []func(ctx context.Context)) {
+	for i, callback := range callbacks {
+		if callback == nil {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Logrus().Errorln("shutdown callback", i, "failed:", r)
+				}
+			}()
+			callback(ctx)
+		}()
+	}
+}
+
 // RawGinEngine 获取原始的gin引擎实例
 func RawGinEngine() *gin.Engine {
-	return ginEngine
+	if state := currentState.Load(); state != nil {
+		return state.engine
+	}
+	return nil
 }
@@ -0,0 +1,190 @@
+package ginstarter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInvalidTrustedProxies(t *testing.T) {
+	_, err := newGinEngine(&GinConfig{TrustedProxies: []string{"10.0.0.0/33"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid TrustedProxies") {
+		t.Fatalf("invalid TrustedProxies should return an error, got %v", err)
+	}
+	if _, err = newGinEngine(&GinConfig{TrustedProxies: []string{"10.0.0.0/8", "127.0.0.1"}}); err != nil {
+		t.Fatalf("valid TrustedProxies should be accepted, got %v", err)
+	}
+}
+
+// 生成自签名证书 返回证书及私钥文件路径
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+	custom := &tls.Config{MinVersion: tls.VersionTLS13}
+	tlsConfig, err := newTLSConfig(&GinConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSConfig: custom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsConfig.Certificates) != 1 || tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("certificate should be merged into the custom config, got %d certificates min version %x", len(tlsConfig.Certificates), tlsConfig.MinVersion)
+	}
+	if len(custom.Certificates) != 0 {
+		t.Fatal("custom TLSConfig should not be modified")
+	}
+
+	if _, err = newTLSConfig(&GinConfig{TLSCertFile: certFile + ".missing", TLSKeyFile: keyFile}); err == nil || !strings.Contains(err.Error(), "load tls certificate") {
+		t.Fatalf("missing certificate file should return an error, got %v", err)
+	}
+	if _, err = newTLSConfig(&GinConfig{TLSConfig: &tls.Config{}}); err == nil {
+		t.Fatal("TLSConfig without certificate should return an error")
+	}
+	getCertificate := &tls.Config{GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }}
+	if _, err = newTLSConfig(&GinConfig{TLSConfig: getCertificate}); err != nil {
+		t.Fatalf("TLSConfig with GetCertificate should be accepted, got %v", err)
+	}
+
+	// 使用生成的配置提供HTTPS服务
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{TLSConfig: tlsConfig, Handler: newHandlerEngine(t, GinConfig{}, http.MethodGet, "tls", func(request *Request) (Response, error) {
+		return RespTextPlain(request.RawGinContext().Request.Proto), nil
+	})}
+	go func() {
+		_ = server.ServeTLS(listener, "", "")
+	}()
+	defer server.Close()
+	pool := x509.NewCertPool()
+	certPEM, _ := os.ReadFile(certFile)
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	response, err := client.Get("https://" + listener.Addr().String() + "/tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.TLS == nil || response.TLS.Version != tls.VersionTLS13 {
+		t.Fatalf("expected a TLS 1.3 connection, got %+v", response.TLS)
+	}
+}
+
+// 注册 /version 路由的配置
+func versionConfig(version string) GinConfig {
+	return GinConfig{Routers: []Router{&testRouter{
+		info: &RouterInfo{},
+		handlers: func(router *RouterWrapper) {
+			router.GET("version", func(request *Request) (Response, error) {
+				return RespTextPlain(version), nil
+			})
+		},
+	}}}
+}
+
+func TestReload(t *testing.T) {
+	// GinStarter通过包级变量保存服务及运行状态 测试结束后重置
+	t.Cleanup(func() {
+		once = sync.Once{}
+		server = nil
+		currentState.Store(nil)
+	})
+	starter := &GinStarter{}
+	if err := starter.Reload(versionConfig("v0")); err == nil {
+		t.Fatal("reload before start should return an error")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	starter.Config = versionConfig("v1")
+	starter.Config.Listener = listener
+	if _, err = starter.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_, _, _ = starter.Stop(time.Second)
+	}()
+	version := func() string {
+		response, err := http.Get("http://" + listener.Addr().String() + "/version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return string(body)
+	}
+	if v := version(); v != "v1" {
+		t.Fatalf("expected v1, got %s", v)
+	}
+
+	if err = starter.Reload(versionConfig("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(); v != "v2" {
+		t.Fatalf("reload should replace the engine, got %s", v)
+	}
+	if currentConfig().Listener != listener {
+		t.Fatal("listener should be kept after reload")
+	}
+
+	invalid := versionConfig("v3")
+	invalid.TrustedProxies = []string{"bad"}
+	if err = starter.Reload(invalid); err == nil || !strings.Contains(err.Error(), "invalid TrustedProxies") {
+		t.Fatalf("invalid config should fail to reload, got %v", err)
+	}
+	conflict := versionConfig("v3")
+	conflict.Routers = append(conflict.Routers, conflict.Routers[0])
+	if err = starter.Reload(conflict); err == nil {
+		t.Fatal("conflicting routes should fail to reload")
+	}
+	if v := version(); v != "v2" {
+		t.Fatalf("failed reload should keep the running engine, got %s", v)
+	}
+}
@@ -1,6 +1,10 @@
 package ginstarter
 
-import "github.com/sirupsen/logrus"
+import (
+	"github.com/acexy/golang-toolkit/logger"
+	"github.com/sirupsen/logrus"
+	"io"
+)
 
 type logrusLogger struct {
 	log   *logrus.Logger
@@ -11,3 +15,13 @@ func (l *logrusLogger) Write(p []byte) (n int, err error) {
 	l.log.Log(l.level, string(p))
 	return len(p), nil
 }
+
+// 创建输出到writer的logger 沿用全局logrus的格式及级别
+func newWriterLogger(writer io.Writer) *logrus.Logger {
+	global := logger.Logrus()
+	log := logrus.New()
+	log.SetOutput(writer)
+	log.SetFormatter(global.Formatter)
+	log.SetLevel(global.GetLevel())
+	return log
+}
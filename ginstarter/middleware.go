@@ -0,0 +1,54 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"sort"
+)
+
+// Middleware 中间件 与PreInterceptor/PostInterceptor不同 可同时处理业务路由执行前后的逻辑
+// 在中间件中调用 request.Next() 执行后续处理器 调用 request.AbortWithResponse() 中断请求并响应
+// 如果中间件未调用 request.Next() 将在其返回后继续执行后续处理器
+type Middleware func(request *Request)
+
+func (m Middleware) handlerFunc() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		m(&Request{ctx: ctx})
+	}
+}
+
+// PriorityMiddleware 带执行优先级的中间件
+// Priority 越大越先执行 GlobalMiddlewares中的中间件优先级视为0 相同优先级按注册顺序执行
+type PriorityMiddleware struct {
+	Priority   int
+	Middleware Middleware
+}
+
+// 合并普通中间件与带优先级的中间件 并按优先级稳定排序
+func sortMiddlewares(middlewares []Middleware, priorityMiddlewares []PriorityMiddleware) []Middleware {
+	if len(priorityMiddlewares) == 0 {
+		return middlewares
+	}
+	merged := make([]PriorityMiddleware, 0, len(middlewares)+len(priorityMiddlewares))
+	for _, v := range middlewares {
+		merged = append(merged, PriorityMiddleware{Middleware: v})
+	}
+	merged = append(merged, priorityMiddlewares...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Priority > merged[j].Priority
+	})
+	result := make([]Middleware, len(merged))
+	for i, v := range merged {
+		result[i] = v.Middleware
+	}
+	return result
+}
+
+// 注册中间件
+func useMiddlewares(group gin.IRoutes, middlewares []Middleware) {
+	for i := range middlewares {
+		middleware := middlewares[i]
+		if middleware != nil {
+			group.Use(middleware.handlerFunc())
+		}
+	}
+}
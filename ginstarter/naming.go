@@ -0,0 +1,384 @@
+package ginstarter
+
+import (
+	"bytes"
+	"encoding"
+	stdjson "encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// JsonNamingStrategy 默认JSON解码器的字段命名策略 仅作用于未设置json标签名的结构体字段
+type JsonNamingStrategy int
+
+const (
+	// JsonNamingDefault 不转换 使用Go字段名 与encoding/json一致
+	JsonNamingDefault JsonNamingStrategy = iota
+	// JsonNamingCamelCase 小驼峰 UserID -> userID HTTPServer -> httpServer
+	JsonNamingCamelCase
+	// JsonNamingSnakeCase 蛇形 UserID -> user_id HTTPServer -> http_server
+	JsonNamingSnakeCase
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*stdjson.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// 结构体中参与编码的字段
+type namingField struct {
+	name      string
+	index     []int
+	depth     int
+	omitEmpty bool
+	quoted    bool
+}
+
+// 按命名策略编码Json 结构体字段名按策略转换 其他值使用leaf编码
+type jsonNamingEncoder struct {
+	strategy JsonNamingStrategy
+	fields   sync.Map
+	leaf     func(data any) ([]byte, error)
+}
+
+func newJsonNamingEncoder(strategy JsonNamingStrategy, leaf func(data any) ([]byte, error)) *jsonNamingEncoder {
+	return &jsonNamingEncoder{strategy: strategy, leaf: leaf}
+}
+
+func (e *jsonNamingEncoder) encode(data any) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := e.encodeValue(buffer, reflect.ValueOf(data)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (e *jsonNamingEncoder) writeLeaf(buffer *bytes.Buffer, data any) error {
+	b, err := e.leaf(data)
+	if err != nil {
+		return err
+	}
+	buffer.Write(b)
+	return nil
+}
+
+func (e *jsonNamingEncoder) encodeValue(buffer *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buffer.WriteString("null")
+		return nil
+	}
+	// 自定义了序列化方式的类型保持原样
+	if v.CanInterface() && (v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType)) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			buffer.WriteString("null")
+			return nil
+		}
+		return e.writeLeaf(buffer, v.Interface())
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buffer.WriteString("null")
+			return nil
+		}
+		return e.encodeValue(buffer, v.Elem())
+	case reflect.Struct:
+		return e.encodeStruct(buffer, v)
+	case reflect.Map:
+		return e.encodeMap(buffer, v)
+	case reflect.Slice:
+		if v.IsNil() {
+			buffer.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.writeLeaf(buffer, v.Bytes())
+		}
+		return e.encodeArray(buffer, v)
+	case reflect.Array:
+		return e.encodeArray(buffer, v)
+	case reflect.String:
+		return e.writeLeaf(buffer, v.String())
+	case reflect.Bool:
+		return e.writeLeaf(buffer, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buffer.WriteString(strconv.FormatInt(v.Int(), 10))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buffer.WriteString(strconv.FormatUint(v.Uint(), 10))
+		return nil
+	case reflect.Float32:
+		return e.writeLeaf(buffer, float32(v.Float()))
+	case reflect.Float64:
+		return e.writeLeaf(buffer, v.Float())
+	}
+	return e.writeLeaf(buffer, v.Interface())
+}
+
+func (e *jsonNamingEncoder) encodeStruct(buffer *bytes.Buffer, v reflect.Value) error {
+	buffer.WriteByte('{')
+	first := true
+	for _, field := range e.structFields(v.Type()) {
+		fv, ok := fieldByIndex(v, field.index)
+		if !ok || field.omitEmpty && isEmptyJsonValue(fv) {
+			continue
+		}
+		if !first {
+			buffer.WriteByte(',')
+		}
+		first = false
+		if err := e.writeLeaf(buffer, field.name); err != nil {
+			return err
+		}
+		buffer.WriteByte(':')
+		if field.quoted {
+			inner := &bytes.Buffer{}
+			if err := e.encodeValue(inner, fv); err != nil {
+				return err
+			}
+			if err := e.writeLeaf(buffer, inner.String()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.encodeValue(buffer, fv); err != nil {
+			return err
+		}
+	}
+	buffer.WriteByte('}')
+	return nil
+}
+
+func (e *jsonNamingEncoder) encodeMap(buffer *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buffer.WriteString("null")
+		return nil
+	}
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := jsonMapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	buffer.WriteByte('{')
+	for i, item := range entries {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		if err := e.writeLeaf(buffer, item.key); err != nil {
+			return err
+		}
+		buffer.WriteByte(':')
+		if err := e.encodeValue(buffer, item.value); err != nil {
+			return err
+		}
+	}
+	buffer.WriteByte('}')
+	return nil
+}
+
+func (e *jsonNamingEncoder) encodeArray(buffer *bytes.Buffer, v reflect.Value) error {
+	buffer.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		if err := e.encodeValue(buffer, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	buffer.WriteByte(']')
+	return nil
+}
+
+// 获取结构体参与编码的字段 按类型缓存
+func (e *jsonNamingEncoder) structFields(t reflect.Type) []namingField {
+	if v, ok := e.fields.Load(t); ok {
+		return v.([]namingField)
+	}
+	all := e.collectFields(t, nil, 0)
+	// 同名字段保留层级最浅的一个 与encoding/json的嵌入字段规则一致
+	shallowest := make(map[string]int, len(all))
+	for _, f := range all {
+		if depth, ok := shallowest[f.name]; !ok || f.depth < depth {
+			shallowest[f.name] = f.depth
+		}
+	}
+	fields := make([]namingField, 0, len(all))
+	seen := make(map[string]bool, len(all))
+	for _, f := range all {
+		if f.depth != shallowest[f.name] || seen[f.name] {
+			continue
+		}
+		seen[f.name] = true
+		fields = append(fields, f)
+	}
+	e.fields.Store(t, fields)
+	return fields
+}
+
+func (e *jsonNamingEncoder) collectFields(t reflect.Type, index []int, depth int) []namingField {
+	var fields []namingField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, e.collectFields(ft, fieldIndex, depth+1)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = convertJsonName(f.Name, e.strategy)
+		}
+		field := namingField{name: name, index: fieldIndex, depth: depth}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				field.omitEmpty = true
+			case "string":
+				switch f.Type.Kind() {
+				case reflect.Bool, reflect.String,
+					reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+					reflect.Float32, reflect.Float64:
+					field.quoted = true
+				}
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// 按索引获取字段值 途经的嵌入指针为nil时返回false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyJsonValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func jsonMapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) {
+		if key.Kind() == reflect.Pointer && key.IsNil() {
+			return "", nil
+		}
+		b, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", &stdjson.UnsupportedTypeError{Type: key.Type()}
+}
+
+// 按命名策略转换字段名
+func convertJsonName(name string, strategy JsonNamingStrategy) string {
+	switch strategy {
+	case JsonNamingCamelCase:
+		words := splitNameWords(name)
+		if len(words) == 0 {
+			return name
+		}
+		words[0] = strings.ToLower(words[0])
+		for i := 1; i < len(words); i++ {
+			runes := []rune(words[i])
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+		return strings.Join(words, "")
+	case JsonNamingSnakeCase:
+		words := splitNameWords(name)
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		return strings.Join(words, "_")
+	}
+	return name
+}
+
+// 按大小写边界拆分单词 连续大写视为一个缩写词 例如 HTTPServerID -> HTTP Server ID
+func splitNameWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if !unicode.IsUpper(runes[i]) || i == start {
+			continue
+		}
+		prev := runes[i-1]
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
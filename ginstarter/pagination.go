@@ -0,0 +1,90 @@
+package ginstarter
+
+import (
+	"strconv"
+)
+
+const (
+	defaultPaginationPageParam = "page"
+	defaultPaginationSizeParam = "size"
+	defaultPaginationSize      = 10
+	defaultPaginationMaxSize   = 100
+	defaultCursorParam         = "cursor"
+)
+
+// PaginationConfig 分页参数配置
+type PaginationConfig struct {
+	// 页码Query参数名 默认 page 页码从1开始
+	PageParam string
+	// 每页数量Query参数名 默认 size
+	SizeParam string
+	// 未传递每页数量时的默认值 默认10
+	DefaultSize int
+	// 每页数量的最大值 默认100
+	MaxSize int
+}
+
+// Pagination 分页参数
+type Pagination struct {
+	Page int
+	Size int
+}
+
+// Offset 当前页第一条数据的偏移量
+func (p *Pagination) Offset() int {
+	return (p.Page - 1) * p.Size
+}
+
+// CursorPage 游标分页响应数据 适用于数据量大 不便使用页码偏移量的场景
+type CursorPage struct {
+	// 当前页数据
+	Items any `json:"items"`
+	// 获取下一页时传递的不透明游标 为空表示没有更多数据
+	NextCursor string `json:"nextCursor"`
+	// 是否还有更多数据
+	HasMore bool `json:"hasMore"`
+}
+
+// PaginationMiddleware 分页参数中间件 解析并校验Query中的页码与每页数量
+// 非法的参数(非整数、小于1、超过最大值)将响应RespRestBadParameters 处理器中通过 request.Pagination() 获取
+func PaginationMiddleware(config PaginationConfig) Middleware {
+	if config.PageParam == "" {
+		config.PageParam = defaultPaginationPageParam
+	}
+	if config.SizeParam == "" {
+		config.SizeParam = defaultPaginationSizeParam
+	}
+	if config.DefaultSize <= 0 {
+		config.DefaultSize = defaultPaginationSize
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = defaultPaginationMaxSize
+	}
+	return func(request *Request) {
+		page, ok := parsePaginationParam(request, config.PageParam, 1, 0)
+		if !ok {
+			request.AbortWithResponse(RespRestBadParameters("invalid " + config.PageParam))
+			return
+		}
+		size, ok := parsePaginationParam(request, config.SizeParam, config.DefaultSize, config.MaxSize)
+		if !ok {
+			request.AbortWithResponse(RespRestBadParameters("invalid " + config.SizeParam + ", should be between 1 and " + strconv.Itoa(config.MaxSize)))
+			return
+		}
+		request.ctx.Set(ginCtxKeyPagination, &Pagination{Page: page, Size: size})
+		request.Next()
+	}
+}
+
+// 解析正整数参数 max为0时不限制最大值
+func parsePaginationParam(request *Request, name string, defaultValue, max int) (int, bool) {
+	value, exists := request.GetQueryParam(name)
+	if !exists || value == "" {
+		return defaultValue, true
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 || (max > 0 && number > max) {
+		return 0, false
+	}
+	return number, true
+}
@@ -0,0 +1,108 @@
+package ginstarter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultPanicBodyMaxBytes = 4096
+	// PanicContextKeyRequestBody PanicBodyCaptureMiddleware附加到panic上下文中的请求body的key
+	PanicContextKeyRequestBody = "requestBody"
+
+	redactedMask = "***"
+)
+
+// PanicBodyCaptureConfig panic请求body捕获配置
+type PanicBodyCaptureConfig struct {
+	// 缓存的请求body最大字节数 超出部分截断 默认4096
+	MaxBytes int
+	// 脱敏处理器 在发生panic时对截断后的body进行脱敏 返回值将附加到panic上下文中 不设置则不脱敏
+	// 可使用 RedactJsonFields 按字段名脱敏Json body
+	Redactor func(contentType string, body []byte) []byte
+}
+
+// PanicBodyCaptureMiddleware panic请求body捕获中间件 用于排查线上panic 出于隐私考虑需显式注册
+// 缓存请求body的前MaxBytes个字节 且不影响处理器读取完整body 发生panic时将脱敏后的body以 PanicContextKeyRequestBody
+// 附加到panic上下文中 由PanicSink上报 未发生panic时不做脱敏处理
+func PanicBodyCaptureMiddleware(config ...PanicBodyCaptureConfig) Middleware {
+	var c PanicBodyCaptureConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = defaultPanicBodyMaxBytes
+	}
+	return func(request *Request) {
+		ctx := request.ctx
+		if ctx.Request.Body == nil || ctx.Request.Body == http.NoBody {
+			request.Next()
+			return
+		}
+		body := ctx.Request.Body
+		captured, _ := io.ReadAll(io.LimitReader(body, int64(c.MaxBytes)+1))
+		ctx.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(captured), body), body}
+		defer func() {
+			if panicError := recover(); panicError != nil {
+				truncated := len(captured) > c.MaxBytes
+				if truncated {
+					captured = captured[:c.MaxBytes]
+				}
+				if c.Redactor != nil {
+					captured = c.Redactor(ctx.ContentType(), captured)
+				}
+				value := string(captured)
+				if truncated {
+					value += "...[truncated]"
+				}
+				request.SetPanicContext(PanicContextKeyRequestBody, value)
+				panic(panicError)
+			}
+		}()
+		request.Next()
+	}
+}
+
+// RedactJsonFields 按字段名脱敏Json body的脱敏处理器 字段名不区分大小写 匹配任意层级的字段
+// 非Json或无法解析(例如被截断)的body将整体替换为 *** 避免敏感信息泄露
+func RedactJsonFields(fields ...string) func(contentType string, body []byte) []byte {
+	redactFields := make(map[string]struct{}, len(fields))
+	for _, v := range fields {
+		redactFields[strings.ToLower(v)] = struct{}{}
+	}
+	return func(contentType string, body []byte) []byte {
+		var value any
+		if !strings.Contains(contentType, "json") || json.Unmarshal(body, &value) != nil {
+			return []byte(redactedMask)
+		}
+		result, err := json.Marshal(redactJsonValue(value, redactFields))
+		if err != nil {
+			return []byte(redactedMask)
+		}
+		return result
+	}
+}
+
+func redactJsonValue(value any, fields map[string]struct{}) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if _, ok := fields[strings.ToLower(key)]; ok {
+				v[key] = redactedMask
+			} else {
+				v[key] = redactJsonValue(item, fields)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJsonValue(item, fields)
+		}
+	}
+	return value
+}
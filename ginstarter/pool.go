@@ -0,0 +1,40 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"sync"
+)
+
+var commonRespPool = sync.Pool{
+	New: func() any {
+		data := &ResponseData{}
+		return &commonResp{responseData: data, pooledData: data}
+	},
+}
+
+// AcquireResp 从对象池获取普通响应 用于高吞吐场景减少每个请求的响应对象分配
+// 响应写出且请求处理完成后由框架自动归还对象池 每个请求必须重新获取 不可缓存复用或在请求结束后继续持有
+// 例如 resp := AcquireResp(); resp.Data().SetData(body).SetContentType(gin.MIMEJSON); return resp, nil
+func AcquireResp() Response {
+	return commonRespPool.Get().(*commonResp)
+}
+
+// 请求处理完成后归还对象池中获取的响应
+func releasePooledResp(ctx *gin.Context) {
+	value, ok := ctx.Get(GinCtxKeyResponse)
+	if !ok {
+		return
+	}
+	resp, ok := value.(*commonResp)
+	if !ok || resp.pooledData == nil {
+		return
+	}
+	delete(ctx.Keys, GinCtxKeyResponse)
+	data := resp.pooledData
+	clear(data.headers)
+	clear(data.cookies)
+	*data = ResponseData{headers: data.headers[:0], cookies: data.cookies[:0]}
+	resp.ginFn = nil
+	resp.responseData = data
+	commonRespPool.Put(resp)
+}
@@ -0,0 +1,40 @@
+package ginstarter
+
+import (
+	"github.com/acexy/golang-toolkit/logger"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// RespReverseProxy 将请求反向代理至target 并将上游响应流式返回 可结合 *path 通配路由或gin NoRoute实现网关转发
+// 请求路径将拼接在target路径之后 Host改写为target的Host 并设置X-Forwarded-For/Host/Proto
+// hop-by-hop请求头(Connection、Upgrade等)由httputil.ReverseProxy自动剔除
+// 上游响应体每次写入后立即发送 不在内存中缓存 上游的响应状态码及响应体原样返回 不经过BadHttpCodeResolver重写
+// 客户端断开连接时终止代理且不再响应 上游不可用时响应502
+func RespReverseProxy(target *url.URL) Response {
+	return RespFunc(func(context *gin.Context) {
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(request *httputil.ProxyRequest) {
+				request.SetURL(target)
+				request.SetXForwarded()
+			},
+			// 每次写入后立即Flush 使可重写状态码中间件直接输出
+			FlushInterval: -1,
+			ModifyResponse: func(response *http.Response) error {
+				context.Set(ginCtxKeyKeepHttpStatus, true)
+				return nil
+			},
+			ErrorHandler: func(writer http.ResponseWriter, request *http.Request, err error) {
+				if request.Context().Err() != nil || isBrokenPipeError(err) {
+					logger.Logrus().Debugln("client disconnected while proxying path:", request.URL, "error:", err)
+					return
+				}
+				logger.Logrus().Warningln("reverse proxy error path:", request.URL, "target:", target, "error:", err)
+				writer.WriteHeader(http.StatusBadGateway)
+			},
+		}
+		proxy.ServeHTTP(context.Writer, context.Request)
+	})
+}
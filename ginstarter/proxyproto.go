@@ -0,0 +1,156 @@
+package ginstarter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	proxyProtocolHeaderTimeout = time.Second * 5
+	proxyProtocolV1MaxLength   = 107
+)
+
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// PROXY protocol 监听器 从连接首部读取L4负载均衡传递的真实客户端地址
+// 支持v1(文本)与v2(二进制)格式 未携带PROXY头的连接保持原始地址
+type proxyProtocolListener struct {
+	net.Listener
+}
+
+func newProxyProtocolListener(listener net.Listener) net.Listener {
+	return &proxyProtocolListener{Listener: listener}
+}
+
+func (l *proxyProtocolListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyProtocolConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// 延迟解析PROXY头的连接 解析在连接自身的处理协程中进行 避免阻塞Accept
+type proxyProtocolConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+}
+
+func (c *proxyProtocolConn) readHeader() {
+	c.once.Do(func() {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(proxyProtocolHeaderTimeout))
+		c.remoteAddr, c.err = readProxyProtocolHeader(c.reader)
+		_ = c.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// 读取PROXY头 返回nil地址表示使用连接原始地址
+func readProxyProtocolHeader(reader *bufio.Reader) (net.Addr, error) {
+	peek, err := reader.Peek(len(proxyProtocolV2Signature))
+	if err != nil {
+		if errors.Is(err, io.EOF) || len(peek) == 0 {
+			return nil, nil
+		}
+		if !bytes.HasPrefix(proxyProtocolV2Signature, peek) && !bytes.HasPrefix([]byte("PROXY "), peek) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if bytes.Equal(peek, proxyProtocolV2Signature) {
+		return readProxyProtocolV2(reader)
+	}
+	if bytes.HasPrefix(peek, []byte("PROXY ")) {
+		return readProxyProtocolV1(reader)
+	}
+	return nil, nil
+}
+
+// v1 格式: PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n
+func readProxyProtocolV1(reader *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyProtocolV1MaxLength {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("proxy protocol: bad v1 header")
+	}
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.New("proxy protocol: bad v1 header")
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.Atoi(fields[4])
+	if ip == nil || err != nil || port < 0 || port > 65535 {
+		return nil, errors.New("proxy protocol: bad v1 address")
+	}
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// v2 格式: 12字节签名 + 版本/命令 + 地址族/协议 + 2字节地址长度 + 地址信息
+func readProxyProtocolV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	if header[12]>>4 != 0x2 {
+		return nil, errors.New("proxy protocol: unsupported v2 version")
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+	// LOCAL 命令 例如负载均衡器自身的健康检查
+	if header[12]&0x0f == 0x0 {
+		return nil, nil
+	}
+	switch header[13] >> 4 {
+	case 0x1: // AF_INET
+		if len(payload) < 12 {
+			return nil, errors.New("proxy protocol: bad v2 address")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, errors.New("proxy protocol: bad v2 address")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	}
+	return nil, nil
+}
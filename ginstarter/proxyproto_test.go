@@ -0,0 +1,105 @@
+package ginstarter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// 构造IPv4的v2头
+func proxyProtocolV2Header(command byte, ip net.IP, port uint16) []byte {
+	header := append([]byte{}, proxyProtocolV2Signature...)
+	header = append(header, 0x20|command, 0x11, 0, 12)
+	header = append(header, ip.To4()...)
+	header = append(header, 10, 0, 0, 1)
+	header = binary.BigEndian.AppendUint16(header, port)
+	return binary.BigEndian.AppendUint16(header, 443)
+}
+
+func TestReadProxyProtocolHeader(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   []byte
+		addr   string
+		hasErr bool
+	}{
+		{"v1 tcp4", []byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\nGET"), "203.0.113.7:56324", false},
+		{"v1 tcp6", []byte("PROXY TCP6 2001:db8::1 2001:db8::2 4000 443\r\nGET"), "[2001:db8::1]:4000", false},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\nGET"), "", false},
+		{"v1 bad address", []byte("PROXY TCP4 bad 10.0.0.1 56324 443\r\nGET"), "", true},
+		{"v1 missing crlf", []byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\nGET"), "", true},
+		{"v2 proxy", append(proxyProtocolV2Header(0x1, net.ParseIP("198.51.100.9"), 8080), "GET"...), "198.51.100.9:8080", false},
+		{"v2 local", append(proxyProtocolV2Header(0x0, net.ParseIP("198.51.100.9"), 8080), "GET"...), "", false},
+		{"no header", []byte("GET / HTTP/1.1\r\n"), "", false},
+		{"short request", []byte("GET"), "", false},
+	}
+	for _, c := range cases {
+		reader := bufio.NewReader(bytes.NewReader(c.data))
+		addr, err := readProxyProtocolHeader(reader)
+		if (err != nil) != c.hasErr {
+			t.Fatalf("%s: unexpected error %v", c.name, err)
+		}
+		if c.hasErr {
+			continue
+		}
+		var got string
+		if addr != nil {
+			got = addr.String()
+		}
+		if got != c.addr {
+			t.Fatalf("%s: expected %q, got %q", c.name, c.addr, got)
+		}
+		// 请求数据不应被PROXY头的解析消耗
+		if rest, _ := io.ReadAll(reader); !bytes.HasPrefix(rest, []byte("GET")) {
+			t.Fatalf("%s: request data consumed, left %q", c.name, rest)
+		}
+	}
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	engine := newHandlerEngine(t, GinConfig{DisableForwardedByClientIP: true}, http.MethodGet, "ip", func(request *Request) (Response, error) {
+		return RespTextPlain(request.RequestIP()), nil
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: engine}
+	go func() {
+		_ = server.Serve(newProxyProtocolListener(listener))
+	}()
+	defer server.Close()
+
+	request := func(header []byte) string {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		_, _ = conn.Write(append(header, "GET /ip HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"...))
+		response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			return ""
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return string(body)
+	}
+	if ip := request([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n")); ip != "203.0.113.7" {
+		t.Fatalf("v1 client address expected, got %s", ip)
+	}
+	if ip := request(proxyProtocolV2Header(0x1, net.ParseIP("198.51.100.9"), 8080)); ip != "198.51.100.9" {
+		t.Fatalf("v2 client address expected, got %s", ip)
+	}
+	if ip := request(nil); ip != "127.0.0.1" {
+		t.Fatalf("connection without header should keep remote address, got %s", ip)
+	}
+	if body := request([]byte("PROXY TCP4 bad\r\n")); strings.Contains(body, ".") {
+		t.Fatalf("malformed header should close the connection, got %s", body)
+	}
+}
@@ -1,15 +1,26 @@
 package ginstarter
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"github.com/acexy/golang-toolkit/math/conversion"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"maps"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// TimeRange 未传递时间区间时的默认范围
+const defaultTimeRange = time.Hour * 24
+
 type Request struct {
 	ctx *gin.Context
 }
@@ -19,6 +30,41 @@ func (r *Request) RawGinContext() *gin.Context {
 	return r.ctx
 }
 
+// Next 在中间件中执行后续处理器
+func (r *Request) Next() {
+	r.ctx.Next()
+}
+
+// AbortWithResponse 在中间件中响应指定数据并中断后续处理器
+func (r *Request) AbortWithResponse(response Response) {
+	if response != nil {
+		httpResponse(r.ctx, response)
+	}
+	r.ctx.Abort()
+}
+
+// IsAborted 当前请求是否已被中断
+func (r *Request) IsAborted() bool {
+	return r.ctx.IsAborted()
+}
+
+// StartTime 请求进入框架的时间
+func (r *Request) StartTime() time.Time {
+	if v, ok := r.ctx.Get(ginCtxKeyStartTime); ok {
+		return v.(time.Time)
+	}
+	return time.Time{}
+}
+
+// Elapsed 请求进入框架至今的耗时
+func (r *Request) Elapsed() time.Duration {
+	startTime := r.StartTime()
+	if startTime.IsZero() {
+		return 0
+	}
+	return time.Since(startTime)
+}
+
 // HttpMethod 获取请求方法
 func (r *Request) HttpMethod() string {
 	return r.ctx.Request.Method
@@ -49,11 +95,129 @@ func (r *Request) Proto() string {
 	return r.ctx.Request.Proto
 }
 
+// ContentType 获取请求的ContentType 不包含charset等参数 例如 application/json
+func (r *Request) ContentType() string {
+	return r.ctx.ContentType()
+}
+
+// Accepts 根据Accept请求头(支持q权重)从给定的类型中选择客户端最期望的类型
+// 未携带Accept请求头时返回第一个类型 均不匹配时返回空字符串
+func (r *Request) Accepts(types ...string) string {
+	if len(types) == 0 {
+		return ""
+	}
+	accept := r.GetHeader("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return types[0]
+	}
+	acceptRanges := parseAcceptHeader(accept)
+	var best string
+	var bestQuality float64
+	for _, offer := range types {
+		quality := acceptQuality(acceptRanges, offer)
+		if quality > bestQuality {
+			best = offer
+			bestQuality = quality
+		}
+	}
+	return best
+}
+
+// Respond 根据Accept请求头从GinConfig.ResponseEncoderRegistry中协商响应格式并编码data
+// 均不匹配时使用第一个注册的编码器 编码失败将触发Panic流程
+func (r *Request) Respond(data any) Response {
+	registry := responseEncoderRegistry(r.ctx)
+	contentType := r.Accepts(registry.contentTypes...)
+	if contentType == "" {
+		contentType = registry.contentTypes[0]
+	}
+	encoder := registry.encoders[contentType]
+	if registry == defaultResponseEncoderRegistry && contentType == gin.MIMEJSON {
+		// 默认Json编码器使用当前请求的解码器
+		encoder = responseDataStructDecoder(r.ctx).Decode
+	}
+	body, err := encoder(data)
+	if err != nil {
+		panic(err)
+	}
+	recordResponseSchema(r.ctx, responseSchemaTypeName(data))
+	return NewCommonResp().SetDataToResponse(NewResponseData(contentType, body).AddHeader("Vary", "Accept"))
+}
+
+// IsAjax 是否为AJAX/XHR请求 即携带 X-Requested-With: XMLHttpRequest 请求头
+func (r *Request) IsAjax() bool {
+	return strings.EqualFold(r.GetHeader("X-Requested-With"), "XMLHttpRequest")
+}
+
+// WantsJSON 客户端是否期望Json响应 XHR请求或Accept请求头中application/json的权重高于text/html时返回true
+// 适用于同一处理器向浏览器响应HTML 向XHR响应Json的场景
+func (r *Request) WantsJSON() bool {
+	if r.IsAjax() {
+		return true
+	}
+	if strings.TrimSpace(r.GetHeader("Accept")) == "" {
+		return false
+	}
+	return r.Accepts(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// WithResponseDecoder 仅为当前请求指定Rest响应及RespJson使用的解码器 覆盖GinConfig.ResponseDataStructDecoder
+// 适用于个别接口的特殊编码需求 例如为旧客户端将数字编码为字符串
+func (r *Request) WithResponseDecoder(decoder ResponseDataStructDecoder) *Request {
+	r.ctx.Set(ginCtxKeyRespDecoder, decoder)
+	return r
+}
+
+// DisableTraceId 当前请求的响应不输出TraceId响应头 适用于RespFunc等未使用ResponseData的响应
+func (r *Request) DisableTraceId() {
+	r.ctx.Set(ginCtxKeyNoTraceId, true)
+}
+
+// IfMatch 获取If-Match请求头 用于PUT/PATCH乐观锁场景中比较客户端持有的资源版本
+func (r *Request) IfMatch() (string, bool) {
+	value := strings.TrimSpace(r.GetHeader("If-Match"))
+	return value, value != ""
+}
+
+// IfMatchSatisfied 当前资源的ETag是否满足If-Match条件 未携带If-Match时返回true 不满足时可响应RespRestPreconditionFailed
+// currentETag 为空表示资源不存在 * 在资源存在时满足(包括弱校验ETag) 其余按强比较 任意一侧为弱校验ETag(W/前缀)时不匹配
+func (r *Request) IfMatchSatisfied(currentETag string) bool {
+	ifMatch, ok := r.IfMatch()
+	if !ok {
+		return true
+	}
+	if ifMatch == "*" {
+		return currentETag != ""
+	}
+	if currentETag == "" || strings.HasPrefix(currentETag, "W/") {
+		return false
+	}
+	for _, v := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(v) == currentETag {
+			return true
+		}
+	}
+	return false
+}
+
+// CloseConnection 响应完成后关闭当前连接 适用于RespFunc等未使用ResponseData的响应 仅对HTTP/1.x生效
+func (r *Request) CloseConnection() {
+	closeConnectionAfterResponse(r.ctx)
+}
+
 // RequestIP 尝试获取请求方客户端IP
 func (r *Request) RequestIP() string {
 	return r.ctx.ClientIP()
 }
 
+// 将绑定错误转换为*BindError 校验失败时触发ValidationFailureHook
+func (r *Request) bindError(err error) error {
+	if err != nil {
+		reportValidationFailure(configOf(r.ctx), r.ctx.FullPath(), err)
+	}
+	return newBindError(err)
+}
+
 // --------------- path 路径参数
 
 // GetPathParam 获取path路径参数 /:id
@@ -72,9 +236,20 @@ func (r *Request) GetPathParams(names ...string) map[string]string {
 	return result
 }
 
+// PathParams 获取所有匹配的path路径参数 /:id/*path 适用于转发等无法预知参数名的通用处理器
+func (r *Request) PathParams() map[string]string {
+	result := make(map[string]string, len(r.ctx.Params))
+	for _, v := range r.ctx.Params {
+		result[v.Key] = v.Value
+	}
+	return result
+}
+
 // BindPathParams /:id 绑定结构体用于接收UriPath参数 结构体标签格式 `uri:""`
+// 返回的错误为*BindError 处理器直接返回该错误将响应参数错误
 func (r *Request) BindPathParams(object any) error {
-	return r.ctx.ShouldBindUri(object)
+	recordRequestSchema(r.ctx, SchemaSourcePath, object)
+	return r.bindError(r.ctx.ShouldBindUri(object))
 }
 
 // MustBindPathParams /:id 绑定结构体用于接收UriPath参数 结构体标签格式 `uri:""`
@@ -162,6 +337,32 @@ func (r *Request) MustGetQueryParamArray(name string) []string {
 	return value
 }
 
+// QueryArray 获取重复传递的Query参数值 /?id=1&id=2 去除首尾空白并忽略空值 未传递时返回空切片
+func (r *Request) QueryArray(name string) []string {
+	values, _ := r.ctx.GetQueryArray(name)
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// QueryCSV 获取逗号分隔的Query参数值 /?ids=1,2,3 同时兼容重复传递 去除首尾空白并忽略空值 未传递时返回空切片
+func (r *Request) QueryCSV(name string) []string {
+	values, _ := r.ctx.GetQueryArray(name)
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
+
 // GetQueryParamMap 获取 uri Query参数值 /?name[a]=1&name[b]=2 返回map类型数据
 func (r *Request) GetQueryParamMap(name string) (map[string]string, bool) {
 	return r.ctx.GetQueryMap(name)
@@ -180,9 +381,73 @@ func (r *Request) MustGetQueryParamMap(name string) map[string]string {
 	return v
 }
 
+// TimeRange 解析Query参数中的时间区间 /?from=2024-01-01&to=2024-01-31 适用于报表等按时间范围查询的接口
+// layout 为时间格式 为空时使用time.RFC3339 不含时区的格式按本地时区解析
+// 均未传递时默认最近24小时 仅传递from时to为当前时间 仅传递to时from为to之前24小时
+// 返回的错误为*BindError 处理器直接返回该错误将响应参数错误
+func (r *Request) TimeRange(fromParam, toParam string, layout string) (time.Time, time.Time, error) {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	from, hasFrom, err := r.queryTime(fromParam, layout)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, hasTo, err := r.queryTime(toParam, layout)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !hasTo {
+		to = time.Now()
+	}
+	if !hasFrom {
+		from = to.Add(-defaultTimeRange)
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, &BindError{
+			rawError:   errors.New(fromParam + " is after " + toParam),
+			message:    fromParam + " must not be after " + toParam,
+			statusCode: http.StatusBadRequest,
+		}
+	}
+	return from, to, nil
+}
+
+// MustTimeRange 解析Query参数中的时间区间 规则同TimeRange
+// 任何错误将触发Panic流程中断
+func (r *Request) MustTimeRange(fromParam, toParam string, layout string) (time.Time, time.Time) {
+	from, to, err := r.TimeRange(fromParam, toParam, layout)
+	if err != nil {
+		panic(&internalPanic{
+			statusCode: http.StatusBadRequest,
+			rawError:   err,
+		})
+	}
+	return from, to
+}
+
+// 按layout解析Query参数中的时间 未传递时返回false
+func (r *Request) queryTime(name string, layout string) (time.Time, bool, error) {
+	value := strings.TrimSpace(r.ctx.Query(name))
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, false, &BindError{
+			rawError:   err,
+			message:    name + " mismatch time layout " + layout,
+			statusCode: http.StatusBadRequest,
+		}
+	}
+	return t, true, nil
+}
+
 // BindQueryParams 绑定结构体用于接收Query参数
+// 返回的错误为*BindError 处理器直接返回该错误将响应参数错误
 func (r *Request) BindQueryParams(object any) error {
-	return r.ctx.ShouldBindQuery(object)
+	recordRequestSchema(r.ctx, SchemaSourceQuery, object)
+	return r.bindError(r.ctx.ShouldBindQuery(object))
 }
 
 // MustBindQueryParams 绑定结构体用于接收Query参数以及POST表单符合条件的数据
@@ -199,9 +464,85 @@ func (r *Request) MustBindQueryParams(object any) {
 
 // --------------- body 参数
 
+// 读取并缓存请求body 多次读取或绑定时复用缓存 同时重置请求body以便后续处理器仍可读取
+// limit 大于0时 body超过该字节数将返回*http.MaxBytesError
+func (r *Request) bodyBytes(limit int64) ([]byte, error) {
+	if v, ok := r.ctx.Get(gin.BodyBytesKey); ok {
+		if body, ok := v.([]byte); ok {
+			if limit > 0 && int64(len(body)) > limit {
+				return nil, &http.MaxBytesError{Limit: limit}
+			}
+			return body, nil
+		}
+	}
+	if r.ctx.Request.Body == nil {
+		return nil, nil
+	}
+	original := r.ctx.Request.Body
+	var reader io.Reader = original
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		// 还原已读取的部分 不设置绑定限制的后续读取仍可获取完整的body
+		r.ctx.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), original), original}
+		return nil, &http.MaxBytesError{Limit: limit}
+	}
+	r.ctx.Set(gin.BodyBytesKey, body)
+	r.ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
 // BindBodyJson 将请求body数据绑定到json结构体中
+// 请求body将被缓存 可多次绑定 或在中间件中读取body后仍可在处理器中绑定
+// 返回的错误为*BindError 处理器直接返回该错误将响应参数错误 body超过GinConfig.MaxBindBodyBytes时响应413
 func (r *Request) BindBodyJson(object any) error {
-	return r.ctx.ShouldBindJSON(object)
+	return r.bindBodyJson(object, configOf(r.ctx).DisallowUnknownJsonFields)
+}
+
+// BindBodyJsonStrict 将请求body数据绑定到json结构体中 body包含结构体未定义的字段时返回错误并响应参数错误
+// 不受GinConfig.DisallowUnknownJsonFields影响 适用于单个接口启用严格校验
+func (r *Request) BindBodyJsonStrict(object any) error {
+	return r.bindBodyJson(object, true)
+}
+
+// MustBindBodyJsonStrict 将请求body数据绑定到json结构体中 body包含结构体未定义的字段时将触发Panic流程中断
+// 任何错误将触发Panic流程中断
+func (r *Request) MustBindBodyJsonStrict(object any) {
+	err := r.BindBodyJsonStrict(object)
+	if err != nil {
+		panic(&internalPanic{
+			statusCode: http.StatusBadRequest,
+			rawError:   err,
+		})
+	}
+}
+
+func (r *Request) bindBodyJson(object any, disallowUnknownFields bool) error {
+	recordRequestSchema(r.ctx, SchemaSourceJson, object)
+	body, err := r.bodyBytes(configOf(r.ctx).MaxBindBodyBytes)
+	if err != nil {
+		return newBindError(err)
+	}
+	if !disallowUnknownFields {
+		return r.bindError(binding.JSON.BindBody(body, object))
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if binding.EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	if err = decoder.Decode(object); err != nil {
+		return r.bindError(err)
+	}
+	return r.bindError(binding.Validator.ValidateStruct(object))
 }
 
 // MustBindBodyJson 将请求body数据绑定到json结构体中
@@ -216,9 +557,21 @@ func (r *Request) MustBindBodyJson(object any) {
 	}
 }
 
-// BindBodyForm 将请求body表单数据绑定到from结构体中
+// BindBodyForm 将请求body表单数据绑定到from结构体中 请求body将被缓存 可在其他绑定中再次读取
+// 返回的错误为*BindError 处理器直接返回该错误将响应参数错误 body超过GinConfig.MaxBindBodyBytes时响应413
 func (r *Request) BindBodyForm(object any) error {
-	return r.ctx.ShouldBindWith(object, binding.FormPost)
+	recordRequestSchema(r.ctx, SchemaSourceForm, object)
+	if r.ctx.Request.PostForm == nil {
+		// 与Json绑定一致 按MaxBindBodyBytes读取并缓存body 超过限制时还原已读取的部分
+		body, err := r.bodyBytes(configOf(r.ctx).MaxBindBodyBytes)
+		if err != nil {
+			return newBindError(err)
+		}
+		if body != nil {
+			r.ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+	return r.bindError(r.ctx.ShouldBindWith(object, binding.FormPost))
 }
 
 // MustBindBodyForm 将请求body表单数据绑定到from结构体中
@@ -233,9 +586,55 @@ func (r *Request) MustBindBodyForm(object any) {
 	}
 }
 
+// ShouldBindJSON 将请求body数据绑定到json结构体中并校验 同 BindBodyJson
+func (r *Request) ShouldBindJSON(object any) error {
+	return r.BindBodyJson(object)
+}
+
+// ShouldBindQuery 绑定结构体用于接收Query参数并校验 同 BindQueryParams
+func (r *Request) ShouldBindQuery(object any) error {
+	return r.BindQueryParams(object)
+}
+
+// ShouldBindUri 绑定结构体用于接收UriPath参数并校验 同 BindPathParams
+func (r *Request) ShouldBindUri(object any) error {
+	return r.BindPathParams(object)
+}
+
+// BindAndValidate 根据请求方法及Content-Type绑定参数并执行校验标签
+// GET/HEAD/DELETE请求绑定Query参数 json请求绑定body 其他请求按Content-Type绑定
+// 失败时返回可直接响应的参数错误 校验失败的字段以 字段: 标签 的形式列出 例如 email: required
+// 例如 if response, ok := request.BindAndValidate(&user); !ok { return response, nil }
+func (r *Request) BindAndValidate(object any) (Response, bool) {
+	var err error
+	switch {
+	case r.ctx.Request.Method == http.MethodGet || r.ctx.Request.Method == http.MethodHead || r.ctx.Request.Method == http.MethodDelete:
+		err = r.BindQueryParams(object)
+	case r.ctx.ContentType() == binding.MIMEJSON:
+		err = r.BindBodyJson(object)
+	case r.ctx.ContentType() == binding.MIMEPOSTForm:
+		err = r.BindBodyForm(object)
+	default:
+		err = r.bindError(r.ctx.ShouldBind(object))
+	}
+	if err == nil {
+		return nil, true
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return RespRestBadParameters(validationFieldsMessage(validationErrs)), false
+	}
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		return bindErr.response(), false
+	}
+	return RespRestBadParameters(err.Error()), false
+}
+
 // GetRawBodyData 将请求body以字节数据返回
+// 请求body将被缓存 多次调用返回相同数据 且不影响后续的BindBodyJson
 func (r *Request) GetRawBodyData() ([]byte, error) {
-	return r.ctx.GetRawData()
+	return r.bodyBytes(0)
 }
 
 // MustGetRawBodyData 将请求body以字节数据返回
@@ -312,14 +711,19 @@ func (r *Request) MustGetFormMap(name string) map[string]string {
 }
 
 // GetFormFile 获取上传文件内容
+// 返回的错误为*BindError 处理器直接返回该错误将响应参数错误 表单超过限制时响应413
 func (r *Request) GetFormFile(name string) (*multipart.FileHeader, error) {
-	return r.ctx.FormFile(name)
+	file, err := r.ctx.FormFile(name)
+	if err != nil {
+		return nil, newBindError(err)
+	}
+	return file, nil
 }
 
 // MustGetFormFile 获取上传文件内容
 // 任何错误将触发Panic流程中断
 func (r *Request) MustGetFormFile(name string) *multipart.FileHeader {
-	v, err := r.ctx.FormFile(name)
+	v, err := r.GetFormFile(name)
 	if err != nil {
 		panic(&internalPanic{
 			statusCode: http.StatusBadRequest,
@@ -356,6 +760,81 @@ func (r *Request) MustSaveUploadedFile(name string, dirPath string, filename ...
 	}
 }
 
+// StreamUploadedFile 将上传的文件内容直接写入dst 不落地磁盘 返回写入的字节数 存在多个同名文件时仅处理第一个
+// 未解析过表单时将以流的方式读取请求体 读取后无法再获取该文件之后的表单参数
+func (r *Request) StreamUploadedFile(fieldName string, dst io.Writer) (int64, error) {
+	var written int64
+	err := r.eachUploadedFile(fieldName, func(filename string, file io.Reader) (bool, error) {
+		n, err := io.Copy(dst, file)
+		written = n
+		return false, err
+	})
+	return written, err
+}
+
+// StreamUploadedFiles 将上传的多个同名文件依次写入dst函数返回的Writer 不落地磁盘 返回写入的总字节数
+// 未解析过表单时将以流的方式读取请求体 读取后无法再获取其他表单参数
+func (r *Request) StreamUploadedFiles(fieldName string, dst func(filename string) (io.Writer, error)) (int64, error) {
+	var written int64
+	err := r.eachUploadedFile(fieldName, func(filename string, file io.Reader) (bool, error) {
+		writer, err := dst(filename)
+		if err != nil {
+			return false, err
+		}
+		n, err := io.Copy(writer, file)
+		written += n
+		return true, err
+	})
+	return written, err
+}
+
+// 遍历上传的同名文件 fn返回false时停止遍历 未找到文件时返回http.ErrMissingFile
+func (r *Request) eachUploadedFile(fieldName string, fn func(filename string, file io.Reader) (bool, error)) error {
+	found := false
+	if form := r.ctx.Request.MultipartForm; form != nil {
+		for _, header := range form.File[fieldName] {
+			found = true
+			file, err := header.Open()
+			if err != nil {
+				return err
+			}
+			next, err := fn(header.Filename, file)
+			_ = file.Close()
+			if err != nil || !next {
+				return err
+			}
+		}
+	} else {
+		reader, err := r.ctx.Request.MultipartReader()
+		if err != nil {
+			return newBindError(err)
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return newBindError(err)
+			}
+			if part.FormName() != fieldName || part.FileName() == "" {
+				_ = part.Close()
+				continue
+			}
+			found = true
+			next, err := fn(part.FileName(), part)
+			_ = part.Close()
+			if err != nil || !next {
+				return err
+			}
+		}
+	}
+	if !found {
+		return newBindError(http.ErrMissingFile)
+	}
+	return nil
+}
+
 // GetHeader 获取Head name对应的参数值
 func (r *Request) GetHeader(name string) string {
 	return r.ctx.GetHeader(name)
@@ -388,3 +867,137 @@ func (r *Request) SetValue(key string, value interface{}) {
 func (r *Request) GetValue(key string) (interface{}, bool) {
 	return r.ctx.Get(key)
 }
+
+// TraceContext 获取W3C链路信息 需要启用TracePropagationMiddleware 未启用时返回nil
+func (r *Request) TraceContext() *TraceContext {
+	return TraceContextFromContext(r.ctx.Request.Context())
+}
+
+// RequestID 获取当前请求的请求ID 需启用GinConfig.AutoRequestID或注册RequestIDMiddleware
+func (r *Request) RequestID() string {
+	return RequestIDFromContext(r.ctx.Request.Context())
+}
+
+// OperationName 获取当前路由通过 RouterWrapper.Operation 指定的操作名 未指定时返回空字符串
+// 可在中间件中使用 作为指标标签或链路span名称
+func (r *Request) OperationName() string {
+	return stateOf(r.ctx).getOperationName(r.ctx.Request.Method, r.ctx.FullPath())
+}
+
+// Pagination 获取分页参数 需注册PaginationMiddleware 未注册时返回nil
+func (r *Request) Pagination() *Pagination {
+	if v, ok := r.ctx.Get(ginCtxKeyPagination); ok {
+		return v.(*Pagination)
+	}
+	return nil
+}
+
+// Cursor 获取游标分页的Query参数 cursor 首页请求未传递时返回空字符串 配合RespRestCursorPage使用
+func (r *Request) Cursor() string {
+	return strings.TrimSpace(r.ctx.Query(defaultCursorParam))
+}
+
+// FeatureEnabled 判断当前请求是否启用了指定特性 需注册FeatureFlagMiddleware 未注册或未解析到该特性时返回false
+func (r *Request) FeatureEnabled(name string) bool {
+	if v, ok := r.ctx.Get(ginCtxKeyFeatureFlags); ok {
+		return v.(map[string]bool)[name]
+	}
+	return false
+}
+
+// ApiVersion 获取当前请求的接口版本 需注册ApiVersionMiddleware
+func (r *Request) ApiVersion() string {
+	return r.ctx.GetString(ginCtxKeyApiVersion)
+}
+
+// SetPanicContext 向panic上下文中附加业务数据 当请求发生panic时将传递给PanicSink 用于丰富错误报告
+func (r *Request) SetPanicContext(key string, value any) {
+	if v, ok := r.ctx.Get(ginCtxKeyPanicContext); ok {
+		v.(map[string]any)[key] = value
+		return
+	}
+	r.ctx.Set(ginCtxKeyPanicContext, map[string]any{key: value})
+}
+
+// PanicContext 获取已附加的panic上下文数据
+func (r *Request) PanicContext() map[string]any {
+	if v, ok := r.ctx.Get(ginCtxKeyPanicContext); ok {
+		return v.(map[string]any)
+	}
+	return nil
+}
+
+// Go 在新的goroutine中执行fn 并捕获fn中的panic 记录错误日志并交由PanicSink上报 避免panic导致进程退出
+// fn中不应继续使用当前请求的上下文 传递给PanicSink的请求为启动时的上下文副本
+func (r *Request) Go(fn func()) {
+	request := &Request{ctx: r.ctx.Copy()}
+	if panicContext := r.PanicContext(); panicContext != nil {
+		request.ctx.Set(ginCtxKeyPanicContext, maps.Clone(panicContext))
+	}
+	go func() {
+		defer func() {
+			if panicError := recover(); panicError != nil {
+				_, err, _ := panicToError(panicError)
+				if sink := configOf(request.ctx).PanicSink; sink != nil {
+					sink(request, err, request.PanicContext())
+				}
+			}
+		}()
+		fn()
+	}()
+}
+
+// Accept请求头中的媒体类型范围
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAcceptHeader(accept string) []acceptRange {
+	parts := strings.Split(accept, ",")
+	ranges := make([]acceptRange, 0, len(parts))
+	for _, part := range parts {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(key) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// 计算给定类型在Accept中的权重 优先使用最精确匹配的媒体类型范围
+func acceptQuality(ranges []acceptRange, offer string) float64 {
+	offer = strings.ToLower(offer)
+	offerType, _, _ := strings.Cut(offer, "/")
+	quality := 0.0
+	specificity := -1
+	for _, v := range ranges {
+		var matched int
+		switch {
+		case v.mediaType == offer:
+			matched = 2
+		case v.mediaType == offerType+"/*":
+			matched = 1
+		case v.mediaType == "*/*" || v.mediaType == "*":
+			matched = 0
+		default:
+			continue
+		}
+		if matched > specificity {
+			specificity = matched
+			quality = v.quality
+		}
+	}
+	return quality
+}
@@ -0,0 +1,174 @@
+package ginstarter
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIfMatchSatisfied(t *testing.T) {
+	cases := []struct {
+		ifMatch     string
+		currentETag string
+		expect      bool
+	}{
+		{"", `"v1"`, true},
+		{"", "", true},
+		{"*", `"v1"`, true},
+		{"*", `W/"v1"`, true},
+		{"*", "", false},
+		{`"v1"`, `"v1"`, true},
+		{`"v1"`, `"v2"`, false},
+		{`"v0", "v1"`, `"v1"`, true},
+		{`"v0","v2"`, `"v1"`, false},
+		{`W/"v1"`, `"v1"`, false},
+		{`"v1"`, `W/"v1"`, false},
+		{`W/"v1"`, `W/"v1"`, false},
+		{`"v1"`, "", false},
+	}
+	for _, c := range cases {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+		if c.ifMatch != "" {
+			ctx.Request.Header.Set("If-Match", c.ifMatch)
+		}
+		if got := (&Request{ctx: ctx}).IfMatchSatisfied(c.currentETag); got != c.expect {
+			t.Errorf("If-Match %q current %q expect %v, got %v", c.ifMatch, c.currentETag, c.expect, got)
+		}
+	}
+}
+
+func TestBindBodyFormLimit(t *testing.T) {
+	type form struct {
+		Name string `form:"name"`
+	}
+	engine := newTestEngine(t, GinConfig{MaxBindBodyBytes: 16, Routers: []Router{&testRouter{
+		info: &RouterInfo{GroupPath: "form"},
+		handlers: func(router *RouterWrapper) {
+			router.POST("bind", func(request *Request) (Response, error) {
+				var value form
+				if err := request.BindBodyForm(&value); err != nil {
+					// 超过限制后body仍完整可读
+					body, _ := io.ReadAll(request.RawGinContext().Request.Body)
+					request.RawGinContext().Header("X-Body-Length", strconv.Itoa(len(body)))
+					return nil, err
+				}
+				return RespTextPlain(value.Name), nil
+			})
+		},
+	}}})
+	contentType := "application/x-www-form-urlencoded"
+	recorder := doRequest(engine, http.MethodPost, "/form/bind", strings.NewReader("name=gin"), "Content-Type", contentType)
+	if recorder.Body.String() != "gin" {
+		t.Fatalf("form within limit should bind, got %s", recorder.Body.String())
+	}
+	payload := "name=" + strings.Repeat("x", 32)
+	recorder = doRequest(engine, http.MethodPost, "/form/bind", strings.NewReader(payload), "Content-Type", contentType)
+	if !strings.Contains(recorder.Body.String(), `"statusCode":413`) {
+		t.Fatalf("form over MaxBindBodyBytes should respond 413, got %s", recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Body-Length") != strconv.Itoa(len(payload)) {
+		t.Fatalf("body should be restored after the limit was exceeded, got length %s", recorder.Header().Get("X-Body-Length"))
+	}
+}
+
+// 构造包含单个文件的multipart请求体
+func multipartBody(t *testing.T, size int) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(bytes.Repeat([]byte("x"), size))
+	_ = writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+// upload分组的请求body限制为1KB upload-form分组不限制
+func newUploadEngine(t *testing.T) *gin.Engine {
+	file := func(request *Request) (Response, error) {
+		file, err := request.GetFormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return RespTextPlain(file.Filename), nil
+	}
+	return newTestEngine(t, GinConfig{
+		// 非文件字段最多占用 MaxMultipartMemory + 10MB 内存
+		MaxMultipartMemory: 1024,
+		Routers: []Router{
+			&testRouter{
+				info: &RouterInfo{GroupPath: "upload", MaxBodyBytes: 1024},
+				handlers: func(router *RouterWrapper) {
+					router.POST("file", file)
+					router.POST("must-file", func(request *Request) (Response, error) {
+						return RespTextPlain(request.MustGetFormFile("file").Filename), nil
+					})
+				},
+			},
+			&testRouter{
+				info: &RouterInfo{GroupPath: "upload-form"},
+				handlers: func(router *RouterWrapper) {
+					router.POST("file", file)
+				},
+			},
+		},
+	})
+}
+
+func TestOversizedMultipart(t *testing.T) {
+	engine := newUploadEngine(t)
+	for _, path := range []string{"/upload/file", "/upload/must-file"} {
+		body, contentType := multipartBody(t, 4096)
+		recorder := doRequest(engine, http.MethodPost, path, body, "Content-Type", contentType)
+		if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeUploadLimitExceeded {
+			t.Fatalf("%s oversized multipart should respond 413, got %d %s", path, statusCode, recorder.Body.String())
+		}
+		if !strings.Contains(recorder.Body.String(), "exceeds the limit of 1024 bytes") {
+			t.Fatalf("%s missing limit message %s", path, recorder.Body.String())
+		}
+	}
+
+	body, contentType := multipartBody(t, 16)
+	recorder := doRequest(engine, http.MethodPost, "/upload/file", body, "Content-Type", contentType)
+	if recorder.Body.String() != "a.txt" {
+		t.Fatalf("small multipart should be accepted, got %s", recorder.Body.String())
+	}
+}
+
+func TestMultipartMemoryExceeded(t *testing.T) {
+	engine := newUploadEngine(t)
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	// 非文件字段超过 MaxMultipartMemory + 10MB 时ReadForm返回multipart.ErrMessageTooLarge
+	_ = writer.WriteField("data", strings.Repeat("x", 10<<20+2048))
+	_ = writer.Close()
+	recorder := doRequest(engine, http.MethodPost, "/upload-form/file", body, "Content-Type", writer.FormDataContentType())
+	if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeUploadLimitExceeded {
+		t.Fatalf("multipart exceeding memory limit should respond 413, got %d %s", statusCode, recorder.Body.String())
+	}
+	if !strings.Contains(recorder.Body.String(), "multipart form exceeds the memory limit") {
+		t.Fatalf("missing memory limit message %s", recorder.Body.String())
+	}
+}
+
+func TestBadMultipart(t *testing.T) {
+	engine := newUploadEngine(t)
+	recorder := doRequest(engine, http.MethodPost, "/upload/file", strings.NewReader("a=b"), "Content-Type", "application/x-www-form-urlencoded")
+	if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeBadRequestParameters {
+		t.Fatalf("non multipart request should respond 400, got %s", recorder.Body.String())
+	}
+	body, contentType := multipartBody(t, 16)
+	contentType = strings.Replace(contentType, "boundary=", "boundary=other", 1)
+	recorder = doRequest(engine, http.MethodPost, "/upload/must-file", body, "Content-Type", contentType)
+	if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeBadRequestParameters {
+		t.Fatalf("malformed multipart should respond 400, got %s", recorder.Body.String())
+	}
+}
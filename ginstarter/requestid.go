@@ -0,0 +1,48 @@
+package ginstarter
+
+const (
+	defaultRequestIDHeader = "X-Request-Id"
+	requestIDMaxLength     = 128
+)
+
+// RequestIDConfig 请求ID配置
+type RequestIDConfig struct {
+	// 读取与响应请求ID的请求头 默认 X-Request-Id
+	HeaderName string
+	// 请求ID生成器 默认生成32位16进制字符
+	Generator func() string
+	// 忽略请求中携带的请求ID 总是重新生成 适用于不信任上游调用方的场景
+	IgnoreIncoming bool
+}
+
+// RequestIDMiddleware 请求ID中间件 沿用请求中携带的请求ID或生成新的请求ID 并写入响应头
+// 处理器中通过 request.RequestID() 获取 访问日志将自动携带该请求ID
+func RequestIDMiddleware(config ...RequestIDConfig) Middleware {
+	var c RequestIDConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.HeaderName == "" {
+		c.HeaderName = defaultRequestIDHeader
+	}
+	if c.Generator == nil {
+		c.Generator = func() string {
+			return randomHex(16)
+		}
+	}
+	return func(request *Request) {
+		var requestID string
+		if !c.IgnoreIncoming {
+			requestID = request.GetHeader(c.HeaderName)
+			if len(requestID) > requestIDMaxLength {
+				requestID = ""
+			}
+		}
+		if requestID == "" {
+			requestID = c.Generator()
+		}
+		setContextValue(request.ctx, contextKeyRequestID, requestID)
+		request.ctx.Header(c.HeaderName, requestID)
+		request.Next()
+	}
+}
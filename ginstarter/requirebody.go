@@ -0,0 +1,53 @@
+package ginstarter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// RequireBodyMiddleware 请求body必填中间件 POST/PUT/PATCH请求未携带body时响应RespRestBadParameters
+// 其他请求方法不做校验 skipRoutes 为不校验的路由模板 与注册的路由路径(request.RouterFullPath)精确匹配
+func RequireBodyMiddleware(skipRoutes ...string) Middleware {
+	skip := make(map[string]struct{}, len(skipRoutes))
+	for _, v := range skipRoutes {
+		skip[v] = struct{}{}
+	}
+	return func(request *Request) {
+		method := request.HttpMethod()
+		if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch {
+			request.Next()
+			return
+		}
+		if _, ok := skip[request.RouterFullPath()]; ok {
+			request.Next()
+			return
+		}
+		if isEmptyBody(request.ctx.Request) {
+			request.AbortWithResponse(RespRestBadParameters("request body is required"))
+			return
+		}
+		request.Next()
+	}
+}
+
+// 判断请求body是否为空 未知长度时预读一个字节判断 并保证后续仍可完整读取
+func isEmptyBody(request *http.Request) bool {
+	if request.Body == nil || request.Body == http.NoBody || request.ContentLength == 0 {
+		return true
+	}
+	if request.ContentLength > 0 {
+		return false
+	}
+	first := make([]byte, 1)
+	n, err := io.ReadFull(request.Body, first)
+	if n == 0 {
+		return err == io.EOF || err == io.ErrUnexpectedEOF
+	}
+	body := request.Body
+	request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(first[:n]), body), body}
+	return false
+}
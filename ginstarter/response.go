@@ -1,10 +1,20 @@
 package ginstarter
 
 import (
+	"bytes"
+	stdjson "encoding/json"
 	"github.com/acexy/golang-toolkit/logger"
 	"github.com/acexy/golang-toolkit/util/json"
 	"github.com/gin-gonic/gin"
+	"io"
+	"io/fs"
+	"mime"
 	"net/http"
+	neturl "net/url"
+	"path"
+	"reflect"
+	"strconv"
+	"time"
 )
 
 // Response 标准响应 用户可以通过自定义实现该接口定义自己的响应结构体
@@ -24,10 +34,45 @@ type ResponseDataStructDecoder interface {
 
 // 默认解码器
 type responseJsonDataStructDecoder struct {
+	disableEscapeHTML bool
+	// 字段命名策略编码器 为nil时不转换字段名
+	naming *jsonNamingEncoder
+}
+
+func newResponseJsonDataStructDecoder(disableEscapeHTML bool, strategy JsonNamingStrategy) responseJsonDataStructDecoder {
+	decoder := responseJsonDataStructDecoder{disableEscapeHTML: disableEscapeHTML}
+	if strategy != JsonNamingDefault {
+		decoder.naming = newJsonNamingEncoder(strategy, decoder.encode)
+	}
+	return decoder
 }
 
 func (r responseJsonDataStructDecoder) Decode(data any) ([]byte, error) {
-	return json.ToJsonBytesError(data)
+	if r.naming != nil {
+		return r.naming.encode(data)
+	}
+	return r.encode(data)
+}
+
+func (r responseJsonDataStructDecoder) encode(data any) ([]byte, error) {
+	if !r.disableEscapeHTML {
+		return json.ToJsonBytesError(data)
+	}
+	buffer := &bytes.Buffer{}
+	encoder := stdjson.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// 获取当前请求使用的解码器 优先使用request.WithResponseDecoder设置的解码器
+func responseDataStructDecoder(context *gin.Context) ResponseDataStructDecoder {
+	if v, ok := context.Get(ginCtxKeyRespDecoder); ok {
+		return v.(ResponseDataStructDecoder)
+	}
+	return configOf(context).ResponseDataStructDecoder
 }
 
 // restResp 默认的Rest响应结构体
@@ -53,23 +98,18 @@ func (r *restResp) DataBuilder(fn func() *ResponseData) Response {
 	return r
 }
 
-// SetData 设置Rest标准的响应结构
+// SetData 设置Rest标准的响应结构 响应时使用当前请求的解码器编码
 func (r *restResp) SetData(data any) *ResponseData {
-	bytes, err := ginConfig.ResponseDataStructDecoder.Decode(data)
-	if err != nil {
-		panic(err)
-	}
-	r.responseData.data = bytes
+	r.responseData.data = nil
+	r.responseData.payload = data
+	r.responseData.hasPayload = true
+	r.responseData.schemaType = responseSchemaTypeName(data)
 	return r.responseData
 }
 
 // SetDataResponse 设置Rest标准的响应结构 并返回响应体数据
 func (r *restResp) SetDataResponse(data any) Response {
-	bytes, err := ginConfig.ResponseDataStructDecoder.Decode(data)
-	if err != nil {
-		panic(err)
-	}
-	r.responseData.data = bytes
+	r.SetData(data)
 	return r
 }
 
@@ -83,11 +123,30 @@ func RespRestRaw(dataRest *RestRespStruct) Response {
 	return NewRespRest().SetDataResponse(dataRest)
 }
 
-// RespRestSuccess 响应标准格式的Rest成功数据
+// RespRestSuccess 响应标准格式的Rest成功数据 data的响应规则同NewRestSuccess
+// 不传时data为null 一个值时为该值本身 多个值时为数组
 func RespRestSuccess(data ...any) Response {
 	return NewRespRest().SetDataResponse(NewRestSuccess(data...))
 }
 
+// RespRestCursorPage 响应标准格式的Rest游标分页数据 data为CursorPage
+// items 应为切片 为nil时响应空数组 nextCursor 为空表示没有更多数据 客户端将其作为cursor参数获取下一页
+func RespRestCursorPage(items any, nextCursor string) Response {
+	if value := reflect.ValueOf(items); !value.IsValid() || value.Kind() == reflect.Slice && value.IsNil() {
+		items = []any{}
+	}
+	return RespRestSuccess(&CursorPage{Items: items, NextCursor: nextCursor, HasMore: nextCursor != ""})
+}
+
+// RespRestAccepted 响应202及标准格式的Rest成功数据 适用于异步任务提交 Location响应头指向任务状态的查询地址
+func RespRestAccepted(statusURL string, data ...any) Response {
+	rest := NewRespRest()
+	rest.SetData(NewRestSuccess(data...)).
+		SetStatusCode(http.StatusAccepted).
+		AddHeader("Location", statusURL)
+	return rest
+}
+
 // RespRestException 响应标准格式的Rest系统异常错误
 func RespRestException(statusMessage ...string) Response {
 	return NewRespRest().SetDataResponse(NewRestException(statusMessage...))
@@ -103,6 +162,98 @@ func RespRestUnAuthorized(statusMessage ...string) Response {
 	return NewRespRest().SetDataResponse(NewRestUnauthorized(statusMessage...))
 }
 
+// RespRestPreconditionFailed 响应标准格式的Rest前置条件失败错误(412) 适用于If-Match版本不一致的乐观锁场景
+func RespRestPreconditionFailed(statusMessage ...string) Response {
+	var message StatusMessage
+	if len(statusMessage) > 0 {
+		message = StatusMessage(statusMessage[0])
+	}
+	return NewRespRest().SetDataResponse(NewRestStatusError(StatusCodePreconditionFailed, message))
+}
+
+// RespRestServiceUnavailable 响应Http 503及标准格式的Rest服务不可用错误 retryAfter 大于0时设置Retry-After响应头(秒数)
+// 适用于熔断、维护模式及过载保护等场景 响应状态码不经过BadHttpCodeResolver重写
+func RespRestServiceUnavailable(retryAfter time.Duration, statusMessage ...string) Response {
+	var message StatusMessage
+	if len(statusMessage) > 0 {
+		message = StatusMessage(statusMessage[0])
+	}
+	return respRestServiceUnavailable(retryAfterSeconds(retryAfter), message)
+}
+
+func respRestServiceUnavailable(retryAfter string, message StatusMessage) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		body, err := responseDataStructDecoder(context).Decode(NewRestStatusError(StatusCodeServiceUnavailable, message))
+		if err != nil {
+			panic(err)
+		}
+		if retryAfter != "" {
+			context.Header("Retry-After", retryAfter)
+		}
+		context.Set(ginCtxKeyKeepHttpStatus, true)
+		context.Data(http.StatusServiceUnavailable, gin.MIMEJSON, body)
+		context.Abort()
+	}}
+}
+
+// ProblemDetails RFC 7807 错误详情 https://www.rfc-editor.org/rfc/rfc7807
+type ProblemDetails struct {
+	// 错误类型的URI 默认 about:blank
+	Type string
+	// 错误类型的简短描述 默认为Http状态码的标准描述
+	Title string
+	// Http状态码 由RespProblem的status设置
+	Status int
+	// 本次错误的具体描述
+	Detail string
+	// 发生错误的具体资源URI
+	Instance string
+	// 扩展字段 与标准字段同级输出 与标准字段重名时忽略
+	Extensions map[string]any
+}
+
+// MarshalJSON 按RFC 7807输出 扩展字段与标准字段同级
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	body := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		body[k] = v
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	} else {
+		delete(body, "detail")
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	} else {
+		delete(body, "instance")
+	}
+	return stdjson.Marshal(body)
+}
+
+// RespProblem 以RFC 7807 application/problem+json格式响应错误 保持status作为Http状态码 不经过BadHttpCodeResolver重写
+// problem.Type 为空时使用 about:blank problem.Title 为空时使用Http状态码的标准描述
+func RespProblem(status int, problem ProblemDetails) Response {
+	problem.Status = status
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(status)
+	}
+	return &commonResp{ginFn: func(context *gin.Context) {
+		body, err := stdjson.Marshal(problem)
+		if err != nil {
+			panic(err)
+		}
+		context.Set(ginCtxKeyKeepHttpStatus, true)
+		context.Data(status, mimeProblemJson, body)
+	}}
+}
+
 // RespRestStatusError 响应标准格式的Rest状态错误
 func RespRestStatusError(statusCode StatusCode, statusMessage ...StatusMessage) Response {
 	return NewRespRest().SetDataResponse(NewRestStatusError(statusCode, statusMessage...))
@@ -114,9 +265,12 @@ func RespRestBizError(bizErrorCode BizErrorCode, bizErrorMessage BizErrorMessage
 }
 
 // commonResp 普通响应
+// ginFn 优先于 responseData 同时设置时仅执行ginFn 并忽略responseData
 type commonResp struct {
 	ginFn        func(context *gin.Context)
 	responseData *ResponseData
+	// 通过AcquireResp获取时对象池持有的ResponseData 非nil表示该响应来自对象池
+	pooledData *ResponseData
 }
 
 func (c *commonResp) Data() *ResponseData {
@@ -153,6 +307,12 @@ func (c *commonResp) ToResponse() Response {
 	return c
 }
 
+// RespFunc 使用gin原始上下文自定义渲染响应 作为框架Response的扩展出口
+// 适用于框架未封装的高级响应场景 响应逻辑由fn自行完成
+func RespFunc(fn func(context *gin.Context)) Response {
+	return &commonResp{ginFn: fn}
+}
+
 // RespHttpStatusCode 设置响应状态码
 func RespHttpStatusCode(statusCode int) Response {
 	return &commonResp{ginFn: func(context *gin.Context) {
@@ -167,17 +327,81 @@ func RespAbortWithHttpStatusCode(statusCode int) Response {
 	}}
 }
 
+// RespTooManyRequests 响应429并中断请求 retryAfter 大于0时设置Retry-After响应头(秒数)
+func RespTooManyRequests(retryAfter time.Duration) Response {
+	return respAbortWithRetryAfter(http.StatusTooManyRequests, retryAfterSeconds(retryAfter))
+}
+
+// RespTooManyRequestsUntil 响应429并中断请求 Retry-After响应头为deadline对应的HTTP日期(RFC1123) 适用于基于日期退避的客户端
+func RespTooManyRequestsUntil(deadline time.Time) Response {
+	return respAbortWithRetryAfter(http.StatusTooManyRequests, retryAfterDate(deadline))
+}
+
+// RespServiceUnavailable 响应503并中断请求 retryAfter 大于0时设置Retry-After响应头(秒数) 同 RespRestServiceUnavailable
+func RespServiceUnavailable(retryAfter time.Duration) Response {
+	return RespRestServiceUnavailable(retryAfter)
+}
+
+// RespServiceUnavailableUntil 响应503并中断请求 Retry-After响应头为deadline对应的HTTP日期(RFC1123) 适用于基于日期退避的客户端
+// 响应内容同 RespRestServiceUnavailable
+func RespServiceUnavailableUntil(deadline time.Time) Response {
+	return respRestServiceUnavailable(retryAfterDate(deadline), "")
+}
+
+func respAbortWithRetryAfter(statusCode int, retryAfter string) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		if retryAfter != "" {
+			context.Header("Retry-After", retryAfter)
+		}
+		context.AbortWithStatus(statusCode)
+	}}
+}
+
+// 秒数不足1秒时向上取整
+func retryAfterSeconds(retryAfter time.Duration) string {
+	if retryAfter <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10)
+}
+
+func retryAfterDate(deadline time.Time) string {
+	if deadline.IsZero() {
+		return ""
+	}
+	return deadline.UTC().Format(http.TimeFormat)
+}
+
 // RespJson 响应Json数据
+// 与Rest响应共用GinConfig.ResponseDataStructDecoder解码器 可通过替换解码器统一使用更快的Json实现(sonic/jsoniter)
 func RespJson(data any, httpStatusCode ...int) Response {
 	return &commonResp{ginFn: func(context *gin.Context) {
 		statusCode := http.StatusOK
 		if len(httpStatusCode) > 0 {
 			statusCode = httpStatusCode[0]
 		}
-		context.JSON(statusCode, data)
+		bytes, err := responseDataStructDecoder(context).Decode(data)
+		if err != nil {
+			panic(err)
+		}
+		recordResponseSchema(context, responseSchemaTypeName(data))
+		context.Data(statusCode, mimeJsonUtf8, bytes)
 	}}
 }
 
+// RespJsonArray 响应顶层为数组的Json数据 不使用Rest结构包裹 适用于需要直接响应数组的旧客户端
+// items 应为切片或数组 为nil时响应空数组 [] 而不是 null
+func RespJsonArray(items any, httpStatusCode ...int) Response {
+	value := reflect.ValueOf(items)
+	switch {
+	case !value.IsValid(), value.Kind() == reflect.Slice && value.IsNil():
+		items = []any{}
+	case value.Kind() != reflect.Slice && value.Kind() != reflect.Array:
+		logger.Logrus().Warningln("RespJsonArray items is not a slice or array, type:", value.Type())
+	}
+	return RespJson(items, httpStatusCode...)
+}
+
 // RespXml 响应Xml数据
 func RespXml(data any, httpStatusCode ...int) Response {
 	return &commonResp{ginFn: func(context *gin.Context) {
@@ -185,6 +409,7 @@ func RespXml(data any, httpStatusCode ...int) Response {
 		if len(httpStatusCode) > 0 {
 			statusCode = httpStatusCode[0]
 		}
+		recordResponseSchema(context, responseSchemaTypeName(data))
 		context.XML(statusCode, data)
 	}}
 }
@@ -196,6 +421,7 @@ func RespYaml(data any, httpStatusCode ...int) Response {
 		if len(httpStatusCode) > 0 {
 			statusCode = httpStatusCode[0]
 		}
+		recordResponseSchema(context, responseSchemaTypeName(data))
 		context.YAML(statusCode, data)
 	}}
 }
@@ -207,6 +433,7 @@ func RespToml(data any, httpStatusCode ...int) Response {
 		if len(httpStatusCode) > 0 {
 			statusCode = httpStatusCode[0]
 		}
+		recordResponseSchema(context, responseSchemaTypeName(data))
 		context.TOML(statusCode, data)
 	}}
 }
@@ -222,16 +449,122 @@ func RespTextPlain(data string, httpStatusCode ...int) Response {
 	}}
 }
 
-// RespRedirect 响应重定向
+// RespBytes 响应二进制数据 未指定contentType时通过 http.DetectContentType 探测
+func RespBytes(data []byte, contentType ...string) Response {
+	var mimeType string
+	if len(contentType) > 0 && contentType[0] != "" {
+		mimeType = contentType[0]
+	} else {
+		mimeType = http.DetectContentType(data)
+	}
+	return NewCommonResp().SetDataToResponse(NewResponseData(mimeType, data))
+}
+
+// RespAttachmentStream 以附件下载的方式流式响应reader中的数据 reader实现io.Closer时响应结束后自动关闭
+// contentType 为空时使用 application/octet-stream 响应头在读取reader前发送 数据边读边写 不在内存中缓存完整内容
+// 开始输出后的读取错误无法再改变响应 仅记录日志
+func RespAttachmentStream(filename, contentType string, reader io.Reader) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+		if disposition == "" {
+			disposition = "attachment"
+		}
+		context.Set(ginCtxKeyKeepHttpStatus, true)
+		context.Header("Content-Type", contentType)
+		context.Header("Content-Disposition", disposition)
+		context.Status(http.StatusOK)
+		// 发送响应头 此后的写入不再经过可重写状态码中间件的缓存
+		context.Writer.Flush()
+		if _, err := io.Copy(context.Writer, reader); err != nil {
+			if context.Request.Context().Err() != nil || isBrokenPipeError(err) {
+				logger.Logrus().Debugln("client disconnected while downloading path:", context.Request.URL, "error:", err)
+				return
+			}
+			logger.Logrus().Warningln("attachment stream error path:", context.Request.URL, "error:", err)
+		}
+	}}
+}
+
+// RespPrometheus 响应Prometheus文本格式的指标数据 适用于自定义指标端点
+func RespPrometheus(text string) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		context.Data(http.StatusOK, mimePrometheusText, []byte(text))
+	}}
+}
+
+// RespRedirect 响应重定向 默认301
+// 301/302 重定向时客户端可能将POST等请求方法改为GET 如需保持请求方法及body请使用RespRedirectPreserveMethod
 func RespRedirect(url string, httpStatusCode ...int) Response {
 	return &commonResp{ginFn: func(context *gin.Context) {
 		statusCode := http.StatusMovedPermanently
 		if len(httpStatusCode) > 0 {
 			statusCode = httpStatusCode[0]
-			if statusCode < http.StatusMultipleChoices && statusCode > http.StatusPermanentRedirect {
+			if statusCode < http.StatusMultipleChoices || statusCode > http.StatusPermanentRedirect {
 				logger.Logrus().Warningln("Bad redirect status code", statusCode, "maybe not work")
 			}
 		}
 		context.Redirect(statusCode, url)
 	}}
 }
+
+// RespRedirectWithParams 响应重定向并向目标地址追加Query参数 默认301 适用于POST-Redirect-GET流程中传递提示信息
+// 参数将进行URL编码 目标地址已包含同名参数时以params中的值覆盖 其他已有参数及#片段保留
+func RespRedirectWithParams(url string, params map[string]string, httpStatusCode ...int) Response {
+	return RespRedirect(appendQueryParams(url, params), httpStatusCode...)
+}
+
+// 向地址追加Query参数 地址解析失败时原样返回
+func appendQueryParams(target string, params map[string]string) string {
+	if len(params) == 0 {
+		return target
+	}
+	u, err := neturl.Parse(target)
+	if err != nil {
+		logger.Logrus().Warningln("Bad redirect url", target, "params ignored:", err)
+		return target
+	}
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// RespRedirectPreserveMethod 响应保持请求方法的重定向 permanent: true 使用308 false 使用307
+// 客户端重放请求时将保持原请求方法及body 适用于API接口的重定向
+func RespRedirectPreserveMethod(url string, permanent bool) Response {
+	if permanent {
+		return RespRedirect(url, http.StatusPermanentRedirect)
+	}
+	return RespRedirect(url, http.StatusTemporaryRedirect)
+}
+
+// RespFileFS 响应fs.FS中的文件 例如embed.FS 根据文件名检测ContentType 支持Last-Modified协商缓存及Range请求
+// 多区间Range请求以206 multipart/byteranges响应 区间数受GinConfig.MaxByteRanges限制
+// 未设置Cache-Control时默认响应 no-cache 要求客户端每次重新验证 文件不存在时响应404
+func RespFileFS(fsys fs.FS, name string) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		header := context.Writer.Header()
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", "no-cache")
+		}
+		serveFile(context, http.FS(fsys), path.Clean("/"+name))
+	}}
+}
+
+// RespNotModified 响应304 不包含响应体 保留已设置的ETag/Cache-Control等缓存相关响应头
+func RespNotModified() Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		header := context.Writer.Header()
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		context.Status(http.StatusNotModified)
+	}}
+}
@@ -0,0 +1,233 @@
+package ginstarter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRespServiceUnavailable(t *testing.T) {
+	deadline := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	engine := newTestEngine(t, GinConfig{Routers: []Router{&testRouter{
+		info: &RouterInfo{GroupPath: "unavailable"},
+		handlers: func(router *RouterWrapper) {
+			router.GET("rest", func(request *Request) (Response, error) {
+				return RespRestServiceUnavailable(30*time.Second, "maintenance"), nil
+			})
+			router.GET("seconds", func(request *Request) (Response, error) {
+				return RespServiceUnavailable(10 * time.Second), nil
+			})
+			router.GET("until", func(request *Request) (Response, error) {
+				return RespServiceUnavailableUntil(deadline), nil
+			})
+		},
+	}}})
+
+	cases := []struct {
+		path       string
+		retryAfter string
+		message    StatusMessage
+	}{
+		{"/unavailable/rest", "30", "maintenance"},
+		{"/unavailable/seconds", "10", statusMessageServiceUnavailable},
+		{"/unavailable/until", deadline.Format(http.TimeFormat), statusMessageServiceUnavailable},
+	}
+	for _, c := range cases {
+		recorder := doRequest(engine, http.MethodGet, c.path, nil)
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected 503, got %d", c.path, recorder.Code)
+		}
+		if got := recorder.Header().Get("Retry-After"); got != c.retryAfter {
+			t.Fatalf("%s: expected Retry-After %q, got %q", c.path, c.retryAfter, got)
+		}
+		var rest RestRespStruct
+		if err := json.Unmarshal(recorder.Body.Bytes(), &rest); err != nil {
+			t.Fatalf("%s: body is not a rest envelope: %v %s", c.path, err, recorder.Body.String())
+		}
+		if rest.Status.StatusCode != StatusCodeServiceUnavailable || rest.Status.StatusMessage != c.message {
+			t.Fatalf("%s: unexpected status %+v", c.path, rest.Status)
+		}
+	}
+}
+
+func TestExpireCookie(t *testing.T) {
+	engine := newHandlerEngine(t, GinConfig{}, http.MethodGet, "cookie", func(request *Request) (Response, error) {
+		return NewCommonResp().DataBuilder(func() *ResponseData {
+			return NewEmptyResponseData().
+				AddCookie(NewCookie("keep", "value", 3600, "/", "", false, true)).
+				AddCookie(ExpireCookie("session", "/", "example.com")).
+				SetData([]byte("success"))
+		}), nil
+	})
+	cookies := doRequest(engine, http.MethodGet, "/cookie", nil).Header().Values("Set-Cookie")
+	if len(cookies) != 2 {
+		t.Fatalf("expect 2 Set-Cookie headers, got %v", cookies)
+	}
+	if !strings.HasPrefix(cookies[0], "keep=value") || !strings.Contains(cookies[0], "Max-Age=3600") {
+		t.Fatalf("unexpected cookie %s", cookies[0])
+	}
+	for _, part := range []string{"session=;", "Path=/", "Domain=example.com", "Max-Age=0", "Expires=Thu, 01 Jan 1970 00:00:00 GMT"} {
+		if !strings.Contains(cookies[1], part) {
+			t.Fatalf("expire cookie %s missing %s", cookies[1], part)
+		}
+	}
+}
+
+func TestBrokenPipeRecovery(t *testing.T) {
+	engine := newTestEngine(t, GinConfig{Routers: []Router{&testRouter{
+		info: &RouterInfo{},
+		handlers: func(router *RouterWrapper) {
+			router.GET("broken-pipe", func(request *Request) (Response, error) {
+				panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+			})
+			router.GET("panic", func(request *Request) (Response, error) {
+				panic(errors.New("business error"))
+			})
+		},
+	}}})
+	if recorder := doRequest(engine, http.MethodGet, "/broken-pipe", nil); recorder.Body.Len() != 0 {
+		t.Fatalf("broken pipe should not write response, got %s", recorder.Body.String())
+	}
+	if recorder := doRequest(engine, http.MethodGet, "/panic", nil); !strings.Contains(recorder.Body.String(), "business error") {
+		t.Fatalf("panic should be resolved, got %s", recorder.Body.String())
+	}
+}
+
+func TestGinFnPrecedence(t *testing.T) {
+	engine := newHandlerEngine(t, GinConfig{}, http.MethodGet, "fn", func(request *Request) (Response, error) {
+		response := RespFunc(func(context *gin.Context) {
+			context.String(http.StatusOK, "fn")
+		})
+		response.(*commonResp).SetData(NewResponseData("text/plain", []byte("data")))
+		return response, nil
+	})
+	if recorder := doRequest(engine, http.MethodGet, "/fn", nil); recorder.Body.String() != "fn" {
+		t.Fatalf("gin function should take precedence over response data, got %s", recorder.Body.String())
+	}
+}
+
+func TestEmptyBody(t *testing.T) {
+	engine := newTestEngine(t, GinConfig{Routers: []Router{&testRouter{
+		info: &RouterInfo{},
+		handlers: func(router *RouterWrapper) {
+			router.GET("empty", func(request *Request) (Response, error) {
+				return NewCommonResp().DataBuilder(func() *ResponseData {
+					return NewEmptyResponseData().
+						AddHeader("X-Empty", "true").
+						AddCookie(NewCookie("empty", "true", 3600, "/", "", false, true))
+				}), nil
+			})
+			router.GET("empty-status", func(request *Request) (Response, error) {
+				return NewCommonResp().DataBuilder(func() *ResponseData {
+					return NewResponseDataWithStatusCode("", nil, http.StatusNoContent)
+				}), nil
+			})
+		},
+	}}})
+	recorder := doRequest(engine, http.MethodGet, "/empty", nil)
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 {
+		t.Fatalf("unexpected empty body response %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Empty") != "true" || !strings.HasPrefix(recorder.Header().Get("Set-Cookie"), "empty=true") {
+		t.Fatalf("headers and cookies should be kept, got %v", recorder.Header())
+	}
+	recorder = doRequest(engine, http.MethodGet, "/empty-status", nil)
+	if recorder.Code != http.StatusNoContent || recorder.Body.Len() != 0 {
+		t.Fatalf("configured status should be written, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestRestSuccessData(t *testing.T) {
+	engine := newHandlerEngine(t, GinConfig{}, http.MethodGet, "rest", func(request *Request) (Response, error) {
+		count, _ := request.GetQueryParam("count")
+		switch count {
+		case "1":
+			return RespRestSuccess("a"), nil
+		case "2":
+			return RespRestSuccess("a", 1), nil
+		case "slice":
+			return RespRestSuccess([]string{"a", "b"}), nil
+		}
+		return RespRestSuccess(), nil
+	})
+	cases := map[string]string{
+		"":      `"data":null`,
+		"1":     `"data":"a"`,
+		"2":     `"data":["a",1]`,
+		"slice": `"data":["a","b"]`,
+	}
+	for count, expect := range cases {
+		recorder := doRequest(engine, http.MethodGet, "/rest?count="+count, nil)
+		if !strings.Contains(recorder.Body.String(), expect) {
+			t.Fatalf("count %q expect %s, got %s", count, expect, recorder.Body.String())
+		}
+	}
+}
+
+// 第一次读取返回固定数据 之后阻塞至ctx取消后返回EOF
+type blockingReader struct {
+	ctx  context.Context
+	sent bool
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	if !b.sent {
+		b.sent = true
+		return copy(p, "first chunk"), nil
+	}
+	<-b.ctx.Done()
+	return 0, io.EOF
+}
+
+// 记录第一次写入的响应写入器
+type firstWriteRecorder struct {
+	*httptest.ResponseRecorder
+	written chan struct{}
+	once    sync.Once
+}
+
+func (f *firstWriteRecorder) Write(data []byte) (int, error) {
+	n, err := f.ResponseRecorder.Write(data)
+	f.once.Do(func() { close(f.written) })
+	return n, err
+}
+
+func TestAttachmentStream(t *testing.T) {
+	engine := newHandlerEngine(t, GinConfig{}, http.MethodGet, "stream", func(request *Request) (Response, error) {
+		return RespAttachmentStream("data.bin", "", &blockingReader{ctx: request.RawGinContext().Request.Context()}), nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := &firstWriteRecorder{ResponseRecorder: httptest.NewRecorder(), written: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx))
+	}()
+	select {
+	case <-recorder.written:
+	case <-time.After(3 * time.Second):
+		cancel()
+		<-done
+		t.Fatal("first chunk should be written before the reader reaches EOF")
+	}
+	cancel()
+	<-done
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "first chunk" {
+		t.Fatalf("unexpected response %d %s", recorder.Code, recorder.Body.String())
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); disposition != "attachment; filename=data.bin" {
+		t.Fatalf("unexpected Content-Disposition %s", disposition)
+	}
+}
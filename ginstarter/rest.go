@@ -29,33 +29,52 @@ type RestRespStruct struct {
 	Data any `json:"data"`
 }
 
-// IsSuccess 判断RestRespStruct是否为成功状态 (200状态码，且不包含任何业务错误码)
+// IsSuccess 判断RestRespStruct是否为成功状态 (成功状态码，且不包含任何业务错误码)
 func (r *RestRespStruct) IsSuccess() bool {
 	if r.Status != nil {
-		return r.Status.StatusCode == StatusCodeSuccess && r.Status.BizErrorCode == nil
+		return r.Status.StatusCode == successStatusCode() && r.Status.BizErrorCode == nil
 	}
 	return false
 }
 
-// IsSuccessWithData 判断RestRespStruct是否为成功状态 (200状态码，且不包含任何业务错误码，且包含响应数据)
+// IsSuccessWithData 判断RestRespStruct是否为成功状态 (成功状态码，且不包含任何业务错误码，且包含响应数据)
 func (r *RestRespStruct) IsSuccessWithData() bool {
 	if r.Status != nil {
-		return r.Status.StatusCode == StatusCodeSuccess && r.Status.BizErrorCode == nil && r.Data != nil
+		return r.Status.StatusCode == successStatusCode() && r.Status.BizErrorCode == nil && r.Data != nil
 	}
 	return false
 }
 
+// 成功状态码 优先使用GinConfig.SuccessStatusCode
+func successStatusCode() StatusCode {
+	if config := currentConfig(); config != nil && config.SuccessStatusCode != nil {
+		return *config.SuccessStatusCode
+	}
+	return StatusCodeSuccess
+}
+
+// 成功状态描述 优先使用GinConfig.SuccessStatusMessage
+func successStatusMessage() StatusMessage {
+	if config := currentConfig(); config != nil && config.SuccessStatusMessage != "" {
+		return config.SuccessStatusMessage
+	}
+	return statusMessageSuccess
+}
+
 // NewRestSuccess 响应标准成功Rest结构体
+// data 不传时响应 "data":null 传递一个值时响应该值本身(切片不会再包裹) 传递多个值时按传入顺序响应为数组
 func NewRestSuccess(data ...interface{}) *RestRespStruct {
 	result := RestRespStruct{
 		Status: &RestRespStatusStruct{
-			StatusCode:    StatusCodeSuccess,
-			StatusMessage: statusMessageSuccess,
+			StatusCode:    successStatusCode(),
+			StatusMessage: successStatusMessage(),
 			Timestamp:     time.Now().UnixMilli(),
 		},
 	}
-	if len(data) > 0 {
+	if len(data) == 1 {
 		result.Data = data[0]
+	} else if len(data) > 1 {
+		result.Data = data
 	}
 	return &result
 }
@@ -125,8 +144,8 @@ func NewRestStatusError(statusCode StatusCode, statusMessage ...StatusMessage) *
 func NewRestBizError(bizErrorCode BizErrorCode, bizErrorMessage BizErrorMessage) *RestRespStruct {
 	dataRest := RestRespStruct{
 		Status: &RestRespStatusStruct{
-			StatusCode:      StatusCodeSuccess,
-			StatusMessage:   statusMessageSuccess,
+			StatusCode:      successStatusCode(),
+			StatusMessage:   successStatusMessage(),
 			BizErrorCode:    &bizErrorCode,
 			BizErrorMessage: &bizErrorMessage,
 			Timestamp:       time.Now().UnixMilli(),
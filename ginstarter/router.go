@@ -1,17 +1,58 @@
 package ginstarter
 
 import (
+	"github.com/acexy/golang-toolkit/util/coll"
 	"github.com/gin-gonic/gin"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
 )
 
-func registerRouter(g *gin.Engine, routers []Router) {
+// 待自动注册HEAD的GET路由
+type autoHeadRoute struct {
+	group     *gin.RouterGroup
+	path      string
+	handlers  []gin.HandlerFunc
+	operation string
+}
+
+// 待自动注册OPTIONS的MATCH路由
+type autoOptionsRoute struct {
+	group *gin.RouterGroup
+	path  string
+}
+
+// 路由操作名 key为 请求方法+空格+路由全路径 仅在注册路由时写入
+func (s *engineState) registerOperationName(method, fullPath, operation string) {
+	s.operationNames[method+" "+fullPath] = operation
+}
+
+func (s *engineState) getOperationName(method, fullPath string) string {
+	return s.operationNames[method+" "+fullPath]
+}
+
+func registerRouter(state *engineState, routers []Router) {
+	g := state.engine
+	var fallbacks []*groupFallback
 	for _, v := range routers {
 		routerInfo := v.Info()
+		group := g.Group(routerInfo.GroupPath)
+		var groupHandlers []gin.HandlerFunc
+		if routerInfo.MaxBodyBytes > 0 {
+			maxBodyBytes := routerInfo.MaxBodyBytes
+			groupHandlers = append(groupHandlers, func(ctx *gin.Context) {
+				ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodyBytes)
+				ctx.Next()
+			})
+		}
+		for _, middleware := range routerInfo.Middlewares {
+			groupHandlers = append(groupHandlers, middleware.handlerFunc())
+		}
 		if len(routerInfo.Interceptors) > 0 {
-			group := g.Group(routerInfo.GroupPath)
 			for i := range routerInfo.Interceptors {
 				interceptor := routerInfo.Interceptors[i]
-				group.Use(func(ctx *gin.Context) {
+				groupHandlers = append(groupHandlers, func(ctx *gin.Context) {
 					response, continued := interceptor(&Request{ctx: ctx})
 					if !continued {
 						httpResponse(ctx, response)
@@ -20,10 +61,133 @@ func registerRouter(g *gin.Engine, routers []Router) {
 						ctx.Next()
 					}
 				})
-				v.Handlers(&RouterWrapper{routerGroup: group})
 			}
-		} else {
-			v.Handlers(&RouterWrapper{routerGroup: g.Group(routerInfo.GroupPath)})
 		}
+		group.Use(groupHandlers...)
+		if hook, ok := v.(RouterRegisterHook); ok {
+			hook.OnRegister(group)
+		}
+		wrapper := &RouterWrapper{state: state, routerGroup: group, cacheControl: routerInfo.CacheControl}
+		v.Handlers(wrapper)
+		if routerInfo.Fallback != nil {
+			fallbacks = append(fallbacks, &groupFallback{
+				prefix:   group.BasePath(),
+				handlers: append(groupHandlers, wrapper.ginHandlers(nil, routerInfo.Fallback)...),
+			})
+		}
+	}
+	registerAutoHeadRoutes(state)
+	registerAutoOptionsRoutes(state)
+	registerGroupFallbacks(g, fallbacks)
+}
+
+// 分组兜底处理器
+type groupFallback struct {
+	prefix   string
+	handlers []gin.HandlerFunc
+}
+
+func (f *groupFallback) match(requestPath string) bool {
+	prefix := strings.TrimSuffix(f.prefix, "/")
+	return prefix == "" || requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/")
+}
+
+// 通过NoRoute将未匹配的请求交由路径最长的分组兜底处理器处理
+// 各分组的处理器数量不同 NoRoute处理链由选择分组的处理器及按位置执行分组处理器的槽位组成
+// 槽位同样属于gin处理链 分组中间件调用Next时将依次执行后续槽位 与正常路由的处理链行为一致
+func registerGroupFallbacks(g *gin.Engine, fallbacks []*groupFallback) {
+	if len(fallbacks) == 0 {
+		return
+	}
+	sort.SliceStable(fallbacks, func(i, j int) bool {
+		return len(fallbacks[i].prefix) > len(fallbacks[j].prefix)
+	})
+	slots := 0
+	for _, fallback := range fallbacks {
+		slots = max(slots, len(fallback.handlers))
+	}
+	handlers := make([]gin.HandlerFunc, 0, slots+1)
+	handlers = append(handlers, func(ctx *gin.Context) {
+		for _, fallback := range fallbacks {
+			if fallback.match(ctx.Request.URL.Path) {
+				ctx.Set(ginCtxKeyFallback, fallback)
+				ctx.Status(http.StatusOK)
+				return
+			}
+		}
+	})
+	for i := 0; i < slots; i++ {
+		index := i
+		handlers = append(handlers, func(ctx *gin.Context) {
+			if v, ok := ctx.Get(ginCtxKeyFallback); ok {
+				if fallback := v.(*groupFallback); index < len(fallback.handlers) {
+					fallback.handlers[index](ctx)
+				}
+			}
+		})
+	}
+	g.NoRoute(handlers...)
+}
+
+// 所有路由注册完成后再注册自动HEAD路由 避免与显式注册的HEAD路由冲突
+func registerAutoHeadRoutes(state *engineState) {
+	if len(state.autoHeadRoutes) == 0 {
+		return
+	}
+	g := state.engine
+	registered := make(map[string]bool)
+	for _, route := range g.Routes() {
+		if route.Method == http.MethodHead {
+			registered[route.Path] = true
+		}
+	}
+	for _, v := range state.autoHeadRoutes {
+		fullPath := joinPaths(v.group.BasePath(), v.path)
+		if registered[fullPath] {
+			continue
+		}
+		v.group.HEAD(v.path, v.handlers...)
+		if v.operation != "" {
+			state.registerOperationName(http.MethodHead, fullPath, v.operation)
+		}
+	}
+	state.autoHeadRoutes = nil
+}
+
+// 在自动HEAD路由之后注册 使Allow响应头包含该路径最终注册的所有请求方法
+func registerAutoOptionsRoutes(state *engineState) {
+	if len(state.autoOptionsRoutes) == 0 {
+		return
+	}
+	g := state.engine
+	methods := make(map[string][]string)
+	for _, route := range g.Routes() {
+		if !coll.SliceContains(methods[route.Path], route.Method) {
+			methods[route.Path] = append(methods[route.Path], route.Method)
+		}
+	}
+	for _, v := range state.autoOptionsRoutes {
+		fullPath := joinPaths(v.group.BasePath(), v.path)
+		if coll.SliceContains(methods[fullPath], http.MethodOptions) {
+			continue
+		}
+		allow := strings.Join(append(methods[fullPath], http.MethodOptions), ", ")
+		methods[fullPath] = append(methods[fullPath], http.MethodOptions)
+		v.group.OPTIONS(v.path, func(ctx *gin.Context) {
+			ctx.Header("Allow", allow)
+			ctx.Status(http.StatusNoContent)
+		})
+	}
+	state.autoOptionsRoutes = nil
+}
+
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if relativePath[len(relativePath)-1] == '/' && finalPath[len(finalPath)-1] != '/' {
+		return finalPath + "/"
 	}
+	return finalPath
 }
@@ -0,0 +1,81 @@
+package ginstarter
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGroupFallback(t *testing.T) {
+	var events []string
+	wrap := func(name string) Middleware {
+		return func(request *Request) {
+			events = append(events, name+" before")
+			request.Next()
+			events = append(events, name+" after")
+		}
+	}
+	page := strings.Repeat("<html></html>", 200)
+	engine := newTestEngine(t, GinConfig{Routers: []Router{
+		&testRouter{
+			info: &RouterInfo{
+				GroupPath:   "app",
+				Middlewares: []Middleware{wrap("app"), CompressionMiddleware(CompressionConfig{})},
+				Fallback: func(request *Request) (Response, error) {
+					events = append(events, "app fallback")
+					return RespTextPlain(page), nil
+				},
+			},
+			handlers: func(router *RouterWrapper) {
+				router.GET("user", func(request *Request) (Response, error) {
+					return RespTextPlain("user"), nil
+				})
+			},
+		},
+		&testRouter{
+			info: &RouterInfo{
+				GroupPath:   "app/admin",
+				Middlewares: []Middleware{wrap("admin")},
+				Fallback: func(request *Request) (Response, error) {
+					events = append(events, "admin fallback")
+					return RespTextPlain("admin"), nil
+				},
+			},
+			handlers: func(router *RouterWrapper) {},
+		},
+	}})
+
+	recorder := doRequest(engine, http.MethodGet, "/app/settings/profile", nil, "Accept-Encoding", "gzip")
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("fallback should be compressed by group middleware, got %d %v", recorder.Code, recorder.Header())
+	}
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	if string(body) != page {
+		t.Fatalf("unexpected fallback body %q", body)
+	}
+	if got := strings.Join(events, ","); got != "app before,app fallback,app after" {
+		t.Fatalf("middleware should wrap the fallback handler, got %s", got)
+	}
+
+	events = nil
+	recorder = doRequest(engine, http.MethodGet, "/app/admin/users", nil)
+	if recorder.Body.String() != "admin" {
+		t.Fatalf("longest group prefix should handle the request, got %s", recorder.Body.String())
+	}
+	if got := strings.Join(events, ","); got != "admin before,admin fallback,admin after" {
+		t.Fatalf("unexpected middleware order %s", got)
+	}
+
+	if recorder = doRequest(engine, http.MethodGet, "/app/user", nil); recorder.Body.String() != "user" {
+		t.Fatalf("registered route should not fall back, got %s", recorder.Body.String())
+	}
+	if recorder = doRequest(engine, http.MethodGet, "/other", nil); recorder.Code != http.StatusNotFound && !strings.Contains(recorder.Body.String(), "404") {
+		t.Fatalf("path outside fallback groups should respond 404, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
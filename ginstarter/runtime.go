@@ -0,0 +1,110 @@
+package ginstarter
+
+import (
+	"github.com/acexy/golang-toolkit/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RuntimeConfig 运行时可调整的配置 通过UpdateRuntimeConfig并发安全地修改 修改后对后续请求立即生效
+// GinConfig中的其他配置仅在启动或Reload时读取 不应在运行期间直接修改
+type RuntimeConfig struct {
+	// 全局日志级别 例如 debug info warn 为空表示不调整
+	LogLevel string
+	// 维护模式 开启后除MaintenanceAllowPaths外的请求响应503
+	Maintenance bool
+	// 维护模式响应的Retry-After 0 表示不设置
+	MaintenanceRetryAfter time.Duration
+	// 维护模式下仍放行的请求路径 精确匹配 例如健康检查 /health
+	MaintenanceAllowPaths []string
+	// 全局每秒允许的请求数 超出时响应429 0 表示不限制
+	RateLimit int
+}
+
+var (
+	runtimeConfig   atomic.Pointer[RuntimeConfig]
+	runtimeConfigMu sync.Mutex
+	// 全局限流的固定窗口计数
+	rateLimitWindow rateLimitCounter
+)
+
+// 按秒计数的固定窗口
+type rateLimitCounter struct {
+	mu     sync.Mutex
+	second int64
+	count  int
+}
+
+func (c *rateLimitCounter) allow(limit int) bool {
+	now := time.Now().Unix()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now != c.second {
+		c.second = now
+		c.count = 0
+	}
+	if c.count >= limit {
+		return false
+	}
+	c.count++
+	return true
+}
+
+// CurrentRuntimeConfig 获取当前运行时配置的副本
+func CurrentRuntimeConfig() RuntimeConfig {
+	if v := runtimeConfig.Load(); v != nil {
+		config := *v
+		config.MaintenanceAllowPaths = slices.Clone(v.MaintenanceAllowPaths)
+		return config
+	}
+	return RuntimeConfig{}
+}
+
+// UpdateRuntimeConfig 并发安全地修改运行时配置 update中修改的是当前配置的副本 返回后原子替换
+// 例如 ginstarter.UpdateRuntimeConfig(func(c *ginstarter.RuntimeConfig) { c.Maintenance = true })
+func UpdateRuntimeConfig(update func(config *RuntimeConfig)) error {
+	runtimeConfigMu.Lock()
+	defer runtimeConfigMu.Unlock()
+	config := CurrentRuntimeConfig()
+	update(&config)
+	return storeRuntimeConfig(&config)
+}
+
+func storeRuntimeConfig(config *RuntimeConfig) error {
+	if config.LogLevel != "" {
+		level, err := logrus.ParseLevel(config.LogLevel)
+		if err != nil {
+			return err
+		}
+		logger.Logrus().SetLevel(level)
+		if state := currentState.Load(); state != nil && state.accessLogger != nil {
+			state.accessLogger.SetLevel(level)
+		}
+	}
+	runtimeConfig.Store(config)
+	return nil
+}
+
+// 运行时配置中间件 处理维护模式及全局限流
+func runtimeConfigHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		config := runtimeConfig.Load()
+		if config == nil {
+			ctx.Next()
+			return
+		}
+		if config.Maintenance && !slices.Contains(config.MaintenanceAllowPaths, ctx.Request.URL.Path) {
+			(&Request{ctx: ctx}).AbortWithResponse(RespServiceUnavailable(config.MaintenanceRetryAfter))
+			return
+		}
+		if config.RateLimit > 0 && !rateLimitWindow.allow(config.RateLimit) {
+			(&Request{ctx: ctx}).AbortWithResponse(RespTooManyRequests(time.Second))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,152 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+const (
+	SchemaSourcePath  = "path"
+	SchemaSourceQuery = "query"
+	SchemaSourceJson  = "json"
+	SchemaSourceForm  = "form"
+)
+
+// RouteSchemaType 路由绑定的请求参数类型
+type RouteSchemaType struct {
+	// 参数来源 path query json form
+	Source string `json:"source"`
+	// Go类型 例如 dto.UserCreate
+	Type string `json:"type"`
+}
+
+// RouteSchema 调试模式下记录的路由请求/响应类型 可用于生成接口文档
+type RouteSchema struct {
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	RequestTypes  []RouteSchemaType `json:"requestTypes"`
+	ResponseTypes []string          `json:"responseTypes"`
+}
+
+// 按路由记录请求绑定类型及响应数据类型
+type routeSchemaRecorder struct {
+	mu      sync.Mutex
+	schemas map[string]*RouteSchema
+}
+
+func newRouteSchemaRecorder() *routeSchemaRecorder {
+	return &routeSchemaRecorder{schemas: make(map[string]*RouteSchema)}
+}
+
+func (r *routeSchemaRecorder) route(ctx *gin.Context) *RouteSchema {
+	method, path := ctx.Request.Method, ctx.FullPath()
+	key := method + " " + path
+	schema, ok := r.schemas[key]
+	if !ok {
+		schema = &RouteSchema{Method: method, Path: path}
+		r.schemas[key] = schema
+	}
+	return schema
+}
+
+func (r *routeSchemaRecorder) addRequest(ctx *gin.Context, source string, typeName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schema := r.route(ctx)
+	item := RouteSchemaType{Source: source, Type: typeName}
+	for _, v := range schema.RequestTypes {
+		if v == item {
+			return
+		}
+	}
+	schema.RequestTypes = append(schema.RequestTypes, item)
+}
+
+func (r *routeSchemaRecorder) addResponse(ctx *gin.Context, typeName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schema := r.route(ctx)
+	for _, v := range schema.ResponseTypes {
+		if v == typeName {
+			return
+		}
+	}
+	schema.ResponseTypes = append(schema.ResponseTypes, typeName)
+}
+
+// 按路径及方法排序返回记录副本
+func (r *routeSchemaRecorder) snapshot() []RouteSchema {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]RouteSchema, 0, len(r.schemas))
+	for _, v := range r.schemas {
+		result = append(result, RouteSchema{
+			Method:        v.Method,
+			Path:          v.Path,
+			RequestTypes:  append([]RouteSchemaType(nil), v.RequestTypes...),
+			ResponseTypes: append([]string(nil), v.ResponseTypes...),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Method < result[j].Method
+	})
+	return result
+}
+
+// DebugRouteSchemas 获取调试模式下各路由记录的请求绑定类型及响应数据类型
+// 仅在DebugModule开启且设置了DebugRecordSchema时有数据 只包含已被请求过的路由
+func DebugRouteSchemas() []RouteSchema {
+	state := currentState.Load()
+	if state == nil || state.routeSchemas == nil {
+		return nil
+	}
+	return state.routeSchemas.snapshot()
+}
+
+// 类型名称 指针类型取其元素类型
+func schemaTypeName(value any) string {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// 记录请求绑定的目标类型
+func recordRequestSchema(ctx *gin.Context, source string, object any) {
+	routeSchemas := stateOf(ctx).routeSchemas
+	if routeSchemas == nil || ctx.FullPath() == "" {
+		return
+	}
+	if typeName := schemaTypeName(object); typeName != "" {
+		routeSchemas.addRequest(ctx, source, typeName)
+	}
+}
+
+// 响应数据类型名称 Rest结构取其中的业务数据类型
+func responseSchemaTypeName(data any) string {
+	if state := currentState.Load(); state == nil || state.routeSchemas == nil {
+		return ""
+	}
+	if rest, ok := data.(*RestRespStruct); ok {
+		data = rest.Data
+	}
+	return schemaTypeName(data)
+}
+
+// 记录响应数据类型
+func recordResponseSchema(ctx *gin.Context, typeName string) {
+	routeSchemas := stateOf(ctx).routeSchemas
+	if routeSchemas == nil || typeName == "" || ctx.FullPath() == "" {
+		return
+	}
+	routeSchemas.addResponse(ctx, typeName)
+}
@@ -0,0 +1,114 @@
+package ginstarter
+
+import (
+	"github.com/gin-gonic/gin"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const defaultMaxByteRanges = 16
+
+// Static 注册本地目录的静态资源路由
+func (r *RouterWrapper) Static(relativePath, root string) {
+	r.StaticFS(relativePath, http.Dir(root))
+}
+
+// StaticFS 注册静态资源路由 relativePath下的请求将从fs中查找同名文件 可通过http.FS使用embed.FS
+// 客户端支持gzip且存在预压缩的同名 .gz 文件时 直接响应预压缩文件 否则响应原始文件 不支持目录浏览
+func (r *RouterWrapper) StaticFS(relativePath string, fs http.FileSystem) {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+	handler := staticHandler(fs)
+	urlPattern := path.Join(relativePath, "/*filepath")
+	r.routerGroup.GET(urlPattern, handler)
+	r.routerGroup.HEAD(urlPattern, handler)
+}
+
+func staticHandler(fs http.FileSystem) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := path.Clean("/" + ctx.Param("filepath"))
+		if acceptsEncoding(ctx.Request, "gzip") && servePrecompressedFile(ctx, fs, name, ".gz", "gzip") {
+			return
+		}
+		serveFile(ctx, fs, name)
+	}
+}
+
+// 响应文件系统中的文件 由http.ServeContent处理ContentType检测、Last-Modified及Range请求 文件不存在时响应404
+func serveFile(ctx *gin.Context, fs http.FileSystem, name string) {
+	file, err := fs.Open(name)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	limitByteRanges(ctx)
+	http.ServeContent(ctx.Writer, ctx.Request, stat.Name(), stat.ModTime(), file)
+}
+
+// Range请求包含的区间数超过GinConfig.MaxByteRanges时忽略Range请求头 响应完整内容 防止大量小区间请求消耗服务资源
+func limitByteRanges(ctx *gin.Context) {
+	request := ctx.Request
+	rangeHeader := request.Header.Get("Range")
+	if rangeHeader == "" {
+		return
+	}
+	maxRanges := configOf(ctx).MaxByteRanges
+	if maxRanges == 0 {
+		maxRanges = defaultMaxByteRanges
+	}
+	if maxRanges > 0 && strings.Count(rangeHeader, ",")+1 > maxRanges {
+		request.Header.Del("Range")
+	}
+}
+
+// 响应预压缩文件 文件不存在时返回false
+func servePrecompressedFile(ctx *gin.Context, fs http.FileSystem, name, suffix, encoding string) bool {
+	file, err := fs.Open(name + suffix)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		return false
+	}
+	header := ctx.Writer.Header()
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Encoding", encoding)
+	addVary(header, "Accept-Encoding")
+	limitByteRanges(ctx)
+	http.ServeContent(ctx.Writer, ctx.Request, name, stat.ModTime(), file)
+	return true
+}
+
+// 判断客户端是否接受指定的内容编码
+func acceptsEncoding(request *http.Request, encoding string) bool {
+	for _, header := range request.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			value, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if !strings.EqualFold(strings.TrimSpace(value), encoding) && strings.TrimSpace(value) != "*" {
+				continue
+			}
+			params = strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+			if params == "q=0" || params == "q=0.0" || params == "q=0.00" || params == "q=0.000" {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package ginstarter
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// StatusClassCounter 按响应状态码类别(1xx-5xx)统计的请求计数器 相比完整的指标统计更轻量
+// 适用于未接入Prometheus等监控系统时的简单看板
+type StatusClassCounter struct {
+	counts [5]atomic.Int64
+}
+
+// NewStatusClassCounter 创建状态码类别计数器 通过Middleware注册统计中间件 通过Counts获取统计结果
+func NewStatusClassCounter() *StatusClassCounter {
+	return &StatusClassCounter{}
+}
+
+// Middleware 统计中间件 统计处理器设置的原始响应状态码 发生panic的请求计入5xx
+func (c *StatusClassCounter) Middleware() Middleware {
+	return func(request *Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				c.add(http.StatusInternalServerError)
+				panic(err)
+			}
+		}()
+		request.Next()
+		statusCode := responseStatusCode(request.ctx)
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		c.add(statusCode)
+	}
+}
+
+func (c *StatusClassCounter) add(statusCode int) {
+	class := statusCode/100 - 1
+	if class >= 0 && class < len(c.counts) {
+		c.counts[class].Add(1)
+	}
+}
+
+// Counts 获取各状态码类别的请求数 key为 1xx 2xx 3xx 4xx 5xx
+func (c *StatusClassCounter) Counts() map[string]int64 {
+	result := make(map[string]int64, len(c.counts))
+	for i := range c.counts {
+		result[strconv.Itoa(i+1)+"xx"] = c.counts[i].Load()
+	}
+	return result
+}
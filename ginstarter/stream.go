@@ -0,0 +1,152 @@
+package ginstarter
+
+import (
+	"context"
+	"github.com/acexy/golang-toolkit/logger"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const mimeEventStream = "text/event-stream"
+
+// StreamWriter 流式响应写入器
+type StreamWriter interface {
+	// Write 写入数据 需调用Flush才会立即发送至客户端
+	Write(data []byte) (int, error)
+	// Flush 将已写入的数据立即发送至客户端
+	Flush()
+	// Context 请求上下文 客户端断开连接时Done
+	Context() context.Context
+	// WriteEvent 写入一条SSE消息并立即发送 event为空时省略event行 data中的换行将拆分为多行data
+	WriteEvent(event, data string) error
+}
+
+type streamWriter struct {
+	ctx *gin.Context
+}
+
+func (s *streamWriter) Write(data []byte) (int, error) {
+	if err := s.ctx.Request.Context().Err(); err != nil {
+		return 0, err
+	}
+	return s.ctx.Writer.Write(data)
+}
+
+func (s *streamWriter) Flush() {
+	if s.ctx.Request.Context().Err() != nil {
+		return
+	}
+	s.ctx.Writer.Flush()
+}
+
+func (s *streamWriter) Context() context.Context {
+	return s.ctx.Request.Context()
+}
+
+func (s *streamWriter) WriteEvent(event, data string) error {
+	var builder strings.Builder
+	if event != "" {
+		builder.WriteString("event: ")
+		builder.WriteString(event)
+		builder.WriteString("\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		builder.WriteString("data: ")
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\n")
+	if _, err := s.Write([]byte(builder.String())); err != nil {
+		return err
+	}
+	s.Flush()
+	return nil
+}
+
+// 当前活跃的流式连接数
+var activeStreams atomic.Int64
+
+// 占用流式连接名额 超过GinConfig.MaxStreamConnections时返回false
+func acquireStream(config *GinConfig) bool {
+	limit := int64(config.MaxStreamConnections)
+	for {
+		current := activeStreams.Load()
+		if limit > 0 && current >= limit {
+			return false
+		}
+		if activeStreams.CompareAndSwap(current, current+1) {
+			return true
+		}
+	}
+}
+
+// ActiveStreams 当前活跃的流式连接数
+func ActiveStreams() int64 {
+	return activeStreams.Load()
+}
+
+// AcquireStream 为自行处理的长连接(例如websocket)占用流式连接名额 与RespStream/RespSSE共享GinConfig.MaxStreamConnections
+// ok为false时已超过限制 应响应503 ok为true时须在连接结束后调用release 重复调用release无副作用
+func (r *Request) AcquireStream() (release func(), ok bool) {
+	if !acquireStream(configOf(r.ctx)) {
+		return func() {}, false
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			activeStreams.Add(-1)
+		})
+	}, true
+}
+
+// RespStream 流式响应 状态码固定为200 响应头在fn执行前立即发送 不经过BadHttpCodeResolver重写
+// fn中通过StreamWriter写入并Flush数据 客户端断开连接后写入将返回错误 fn应及时结束
+// fn返回的错误无法再改变响应 仅记录日志 活跃连接数超过GinConfig.MaxStreamConnections时响应503
+func RespStream(contentType string, fn func(w StreamWriter) error) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		serveStream(context, contentType, nil, fn)
+	}}
+}
+
+// RespSSE 以Server-Sent Events方式流式响应 通过 w.WriteEvent 发送消息
+func RespSSE(fn func(w StreamWriter) error) Response {
+	return &commonResp{ginFn: func(context *gin.Context) {
+		serveStream(context, mimeEventStream, map[string]string{
+			"Cache-Control": "no-cache",
+			"Connection":    "keep-alive",
+			// 禁用nginx等反向代理的响应缓冲
+			"X-Accel-Buffering": "no",
+		}, fn)
+	}}
+}
+
+func serveStream(context *gin.Context, contentType string, headers map[string]string, fn func(w StreamWriter) error) {
+	release, ok := (&Request{ctx: context}).AcquireStream()
+	if !ok {
+		logger.Logrus().Warningln("too many stream connections path:", context.Request.URL, "active:", ActiveStreams())
+		context.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+	// panic及客户端异常断开时同样释放名额
+	defer release()
+	context.Set(ginCtxKeyKeepHttpStatus, true)
+	if contentType != "" {
+		context.Header("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		context.Header(k, v)
+	}
+	context.Status(http.StatusOK)
+	writer := &streamWriter{ctx: context}
+	writer.Flush()
+	if err := fn(writer); err != nil {
+		if context.Request.Context().Err() != nil || isBrokenPipeError(err) {
+			logger.Logrus().Debugln("client disconnected while streaming path:", context.Request.URL, "error:", err)
+			return
+		}
+		logger.Logrus().Warningln("stream response error path:", context.Request.URL, "error:", err)
+	}
+}
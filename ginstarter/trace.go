@@ -0,0 +1,104 @@
+package ginstarter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	headerTraceParent = "traceparent"
+	headerTraceState  = "tracestate"
+
+	traceParentVersion      = "00"
+	traceParentSampledFlags = "01"
+)
+
+// TraceContext W3C Trace Context 链路信息 https://www.w3.org/TR/trace-context/
+type TraceContext struct {
+	// 链路id 32位16进制字符
+	TraceId string
+	// 上游调用方的span id 新建链路时为空
+	ParentSpanId string
+	// 当前服务处理该请求生成的span id 16位16进制字符
+	SpanId string
+	// 链路标识位 例如 01 表示采样
+	TraceFlags string
+	// 厂商扩展的链路状态 原样传递
+	TraceState string
+}
+
+// TraceParent 当前服务的traceparent值 用于向下游传递链路信息
+func (t *TraceContext) TraceParent() string {
+	return traceParentVersion + "-" + t.TraceId + "-" + t.SpanId + "-" + t.TraceFlags
+}
+
+// TracePropagationMiddleware W3C traceparent/tracestate 链路传播中间件
+// 解析请求的traceparent/tracestate头 生成当前服务的span id 并在响应头中延续链路信息
+// 请求未携带或携带非法traceparent时将新建链路 处理器中通过 request.TraceContext() 获取
+func TracePropagationMiddleware() Middleware {
+	return func(request *Request) {
+		traceContext, ok := parseTraceParent(request.GetHeader(headerTraceParent))
+		if !ok {
+			traceContext = &TraceContext{
+				TraceId:    randomHex(16),
+				TraceFlags: traceParentSampledFlags,
+			}
+		} else {
+			traceContext.TraceState = request.GetHeader(headerTraceState)
+		}
+		traceContext.SpanId = randomHex(8)
+		setContextValue(request.ctx, contextKeyTraceContext, traceContext)
+
+		header := request.ctx.Writer.Header()
+		header.Set(headerTraceParent, traceContext.TraceParent())
+		if traceContext.TraceState != "" {
+			header.Set(headerTraceState, traceContext.TraceState)
+		}
+		request.Next()
+	}
+}
+
+// 解析traceparent 格式 version-traceid-parentid-flags
+func parseTraceParent(traceParent string) (*TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 {
+		return nil, false
+	}
+	version, traceId, parentId, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || (version == traceParentVersion && len(parts) != 4) {
+		return nil, false
+	}
+	if !isLowerHex(traceId, 32) || traceId == strings.Repeat("0", 32) {
+		return nil, false
+	}
+	if !isLowerHex(parentId, 16) || parentId == strings.Repeat("0", 16) {
+		return nil, false
+	}
+	if !isLowerHex(flags, 2) {
+		return nil, false
+	}
+	return &TraceContext{
+		TraceId:      traceId,
+		ParentSpanId: parentId,
+		TraceFlags:   flags,
+	}, true
+}
+
+func isLowerHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for _, c := range value {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(byteLength int) string {
+	b := make([]byte, byteLength)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
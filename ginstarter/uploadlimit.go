@@ -0,0 +1,87 @@
+package ginstarter
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// 未知长度的上传在读取过程中超过全局上传字节数限制
+var errUploadBytesLimitExceeded = errors.New("too many bytes uploading")
+
+// 全局上传中的字节数统计
+type uploadBytesLimiter struct {
+	inflight atomic.Int64
+	max      int64
+}
+
+func (l *uploadBytesLimiter) tryReserve(size int64) bool {
+	for {
+		current := l.inflight.Load()
+		if current+size > l.max {
+			return false
+		}
+		if l.inflight.CompareAndSwap(current, current+size) {
+			return true
+		}
+	}
+}
+
+// 未知长度的上传 按实际读取的字节数统计
+type uploadCountingReader struct {
+	io.ReadCloser
+	limiter *uploadBytesLimiter
+	read    int64
+}
+
+func (r *uploadCountingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if !r.limiter.tryReserve(int64(n)) {
+			return 0, errUploadBytesLimitExceeded
+		}
+		r.read += int64(n)
+	}
+	return n, err
+}
+
+// UploadBytesLimitMiddleware 全局上传字节数限制中间件 限制所有请求中正在上传的multipart数据总字节数
+// 已知长度的上传在开始时按Content-Length预占额度 超过maxInflightBytes时响应503 Content-Length本身超过maxInflightBytes时响应413
+// 未知长度(chunked)的上传在当前总量未超过限制时放行 并按实际读取的字节数统计 读取中超过限制时读取失败并响应503
+// 请求处理结束(包括panic及客户端断开连接)后释放占用的额度
+func UploadBytesLimitMiddleware(maxInflightBytes int64) Middleware {
+	limiter := &uploadBytesLimiter{max: maxInflightBytes}
+	return func(request *Request) {
+		ctx := request.ctx
+		if !strings.HasPrefix(strings.ToLower(ctx.ContentType()), "multipart/") || ctx.Request.Body == nil {
+			request.Next()
+			return
+		}
+		if size := ctx.Request.ContentLength; size >= 0 {
+			if size > limiter.max {
+				// 无论何时都无法满足的上传 重试无意义
+				request.AbortWithResponse(RespAbortWithHttpStatusCode(http.StatusRequestEntityTooLarge))
+				return
+			}
+			if !limiter.tryReserve(size) {
+				request.AbortWithResponse(RespServiceUnavailable(0))
+				return
+			}
+			defer limiter.inflight.Add(-size)
+			request.Next()
+			return
+		}
+		if limiter.inflight.Load() >= limiter.max {
+			request.AbortWithResponse(RespServiceUnavailable(0))
+			return
+		}
+		reader := &uploadCountingReader{ReadCloser: ctx.Request.Body, limiter: limiter}
+		ctx.Request.Body = reader
+		defer func() {
+			limiter.inflight.Add(-reader.read)
+		}()
+		request.Next()
+	}
+}
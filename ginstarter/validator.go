@@ -1,11 +1,13 @@
 package ginstarter
 
 import (
+	"errors"
 	"github.com/acexy/golang-toolkit/util/coll"
 	"github.com/acexy/golang-toolkit/util/str"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"regexp"
+	"sync"
 )
 
 /**
@@ -30,6 +32,37 @@ var typeDesc = []string{
 	"domain",
 }
 
+// ValidationFieldError 参数校验失败的字段信息 不包含请求中的参数值
+type ValidationFieldError struct {
+	// 字段名
+	Field string
+	// 字段在结构体中的完整路径 例如 User.Address.City
+	Namespace string
+	// 校验失败的标签 例如 required max
+	Tag string
+	// 标签参数 例如 max=10 中的 10
+	Param string
+}
+
+// ValidationFailureHook 参数校验失败钩子 route 为注册的路由模板 可用于统计校验失败的字段及标签
+type ValidationFailureHook func(route string, fields []ValidationFieldError)
+
+// 触发参数校验失败钩子
+func reportValidationFailure(config *GinConfig, route string, err error) {
+	if config.ValidationFailureHook == nil {
+		return
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return
+	}
+	fields := make([]ValidationFieldError, len(validationErrs))
+	for i, v := range validationErrs {
+		fields[i] = ValidationFieldError{Field: v.Field(), Namespace: v.Namespace(), Tag: v.Tag(), Param: v.Param()}
+	}
+	config.ValidationFailureHook(route, fields)
+}
+
 // friendlyValidatorMessage 处理验证框架错误，友好展示错误信息
 func friendlyValidatorMessage(errors validator.ValidationErrors) string {
 	builder := str.NewBuilder()
@@ -55,10 +88,30 @@ func friendlyValidatorMessage(errors validator.ValidationErrors) string {
 	return builder.ToString()
 }
 
-func registerValidators() {
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		_ = v.RegisterValidation("domain", domainValidator)
+// 以 字段: 标签 的形式逐个列出校验失败的字段 例如 email: required; age: max=10
+func validationFieldsMessage(errors validator.ValidationErrors) string {
+	builder := str.NewBuilder()
+	for i, vErr := range errors {
+		builder.WriteString(str.LowFirstChar(vErr.Field())).WriteString(": ").WriteString(vErr.Tag())
+		if param := vErr.Param(); param != "" {
+			builder.WriteString("=").WriteString(param)
+		}
+		if i != len(errors)-1 {
+			builder.WriteString("; ")
+		}
 	}
+	return builder.ToString()
+}
+
+var registerValidatorsOnce sync.Once
+
+// 注册拓展验证tag 验证器为全局共享 仅注册一次 避免Reload时与处理中的请求并发修改
+func registerValidators() {
+	registerValidatorsOnce.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			_ = v.RegisterValidation("domain", domainValidator)
+		}
+	})
 }
 
 // 自定义域名验证器
@@ -0,0 +1,118 @@
+package ginstarter
+
+import (
+	"github.com/acexy/golang-toolkit/logger"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// WAFRules 基础WAF规则 用于拦截明显的攻击请求
+type WAFRules struct {
+	// 拦截路径穿越 ../ ..\ 及其编码形式
+	BlockPathTraversal bool
+	// 拦截路径、Query参数及请求头中的空字节
+	BlockNullByte bool
+	// 单个Query参数值的最大长度 0 表示不限制
+	MaxQueryValueLength int
+	// 需要拦截的User-Agent 正则表达式
+	BadUserAgents []string
+	// 自定义拦截规则 正则表达式 作用于解码后的路径与Query参数值
+	Patterns []string
+}
+
+type compiledWAFRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// BasicWAFMiddleware 基础WAF中间件 命中规则的请求将响应403并记录命中的规则
+// 正则在创建中间件时预编译 非法的正则将直接panic
+func BasicWAFMiddleware(rules WAFRules) Middleware {
+	badUserAgents := compileWAFRules("user-agent", rules.BadUserAgents)
+	patterns := compileWAFRules("pattern", rules.Patterns)
+	return func(request *Request) {
+		if rule, blocked := matchWAFRules(request.ctx.Request, &rules, badUserAgents, patterns); blocked {
+			logger.Logrus().Warningln("WAF rule", rule, "blocked request path:", request.ctx.Request.URL.Path, "ip:", request.RequestIP())
+			request.AbortWithResponse(RespAbortWithHttpStatusCode(http.StatusForbidden))
+			return
+		}
+		request.Next()
+	}
+}
+
+func compileWAFRules(kind string, expressions []string) []*compiledWAFRule {
+	compiled := make([]*compiledWAFRule, len(expressions))
+	for i, v := range expressions {
+		compiled[i] = &compiledWAFRule{name: kind + " " + v, pattern: regexp.MustCompile(v)}
+	}
+	return compiled
+}
+
+// 返回命中的规则名
+func matchWAFRules(request *http.Request, rules *WAFRules, badUserAgents, patterns []*compiledWAFRule) (string, bool) {
+	path := request.URL.Path
+	rawPath := request.URL.EscapedPath()
+	rawQuery := request.URL.RawQuery
+	if rules.BlockNullByte {
+		if strings.Contains(path, "\x00") || strings.Contains(rawPath, "%00") || strings.Contains(rawQuery, "%00") {
+			return "null-byte", true
+		}
+		for _, values := range request.Header {
+			for _, v := range values {
+				if strings.Contains(v, "\x00") {
+					return "null-byte", true
+				}
+			}
+		}
+	}
+	if rules.BlockPathTraversal && isPathTraversal(path, rawPath) {
+		return "path-traversal", true
+	}
+	query := request.URL.Query()
+	for _, values := range query {
+		for _, v := range values {
+			if rules.MaxQueryValueLength > 0 && len(v) > rules.MaxQueryValueLength {
+				return "query-length", true
+			}
+			if rules.BlockPathTraversal && isPathTraversal(v) {
+				return "path-traversal", true
+			}
+			if rules.BlockNullByte && strings.Contains(v, "\x00") {
+				return "null-byte", true
+			}
+		}
+	}
+	if len(badUserAgents) > 0 {
+		userAgent := request.UserAgent()
+		for _, v := range badUserAgents {
+			if v.pattern.MatchString(userAgent) {
+				return v.name, true
+			}
+		}
+	}
+	for _, v := range patterns {
+		if v.pattern.MatchString(path) {
+			return v.name, true
+		}
+		for _, values := range query {
+			for _, value := range values {
+				if v.pattern.MatchString(value) {
+					return v.name, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func isPathTraversal(values ...string) bool {
+	for _, v := range values {
+		lower := strings.ToLower(v)
+		if strings.Contains(lower, "../") || strings.Contains(lower, "..\\") || strings.HasSuffix(lower, "/..") ||
+			strings.Contains(lower, "%2e%2e") || strings.Contains(lower, "..%2f") || strings.Contains(lower, "..%5c") {
+			return true
+		}
+	}
+	return false
+}
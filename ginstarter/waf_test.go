@@ -0,0 +1,57 @@
+package ginstarter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBasicWAF(t *testing.T) {
+	engine := newHandlerEngine(t, GinConfig{
+		GlobalMiddlewares: []Middleware{BasicWAFMiddleware(WAFRules{
+			BlockPathTraversal:  true,
+			BlockNullByte:       true,
+			MaxQueryValueLength: 16,
+			BadUserAgents:       []string{`(?i)sqlmap`},
+			Patterns:            []string{`(?i)<script`},
+		})},
+	}, http.MethodGet, "files/*name", func(request *Request) (Response, error) {
+		return RespTextPlain("ok"), nil
+	})
+	cases := []struct {
+		name    string
+		path    string
+		headers []string
+		blocked bool
+	}{
+		{"normal", "/files/a.txt?q=go", nil, false},
+		{"path traversal", "/files/a/../../etc/passwd", nil, true},
+		{"encoded path traversal", "/files/%2e%2e/etc/passwd", nil, true},
+		{"query traversal", "/files/a.txt?f=../secret", nil, true},
+		{"null byte", "/files/a.txt%00.jpg", nil, true},
+		{"query null byte", "/files/a.txt?q=a%00b", nil, true},
+		{"query length", "/files/a.txt?q=" + strings.Repeat("x", 17), nil, true},
+		{"bad user agent", "/files/a.txt", []string{"User-Agent", "sqlmap/1.7"}, true},
+		{"pattern in query", "/files/a.txt?q=%3CScript%3E", nil, true},
+		{"null byte header", "/files/a.txt", []string{"X-Name", "a\x00b"}, true},
+	}
+	for _, c := range cases {
+		recorder := doRequest(engine, http.MethodGet, c.path, nil, c.headers...)
+		if c.blocked {
+			if statusCode := restStatusCode(t, recorder.Body.Bytes()); statusCode != StatusCodeForbidden {
+				t.Fatalf("%s: expected 403, got %d %s", c.name, recorder.Code, recorder.Body.String())
+			}
+		} else if recorder.Body.String() != "ok" {
+			t.Fatalf("%s: request should pass, got %d %s", c.name, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestBasicWAFInvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("invalid pattern should panic when creating the middleware")
+		}
+	}()
+	BasicWAFMiddleware(WAFRules{Patterns: []string{"("}})
+}
@@ -2,12 +2,18 @@ package ginstarter
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/acexy/golang-toolkit/logger"
 	"github.com/acexy/golang-toolkit/sys"
+	"github.com/acexy/golang-toolkit/util/coll"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"mime/multipart"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type BasicAuthAccount struct {
@@ -21,17 +27,120 @@ type internalPanic struct {
 	rawError   error
 }
 
+// BindError 请求参数绑定/校验错误
+// 处理器直接返回该错误时将响应RespRestBadParameters 而不触发panic流程 避免普通的参数校验失败污染panic日志
+type BindError struct {
+	rawError   error
+	message    string
+	statusCode int
+}
+
+func newBindError(rawError error) error {
+	if rawError == nil {
+		return nil
+	}
+	err, statusCode, _ := parseBindError(rawError)
+	return &BindError{rawError: rawError, message: err.Error(), statusCode: statusCode}
+}
+
+func (b *BindError) Error() string {
+	return b.message
+}
+
+func (b *BindError) Unwrap() error {
+	return b.rawError
+}
+
+func (b *BindError) response() Response {
+	switch b.statusCode {
+	case http.StatusRequestEntityTooLarge:
+		return RespRestStatusError(StatusCodeUploadLimitExceeded, StatusMessage(b.message))
+	case http.StatusServiceUnavailable:
+		return RespRestStatusError(StatusCodeServiceUnavailable, StatusMessage(b.message))
+	}
+	return RespRestBadParameters(b.message)
+}
+
+// 解析参数绑定错误 转换为友好的错误信息
+// internalError 标识该错误为非敏感的框架内部错误
+func parseBindError(rawError error) (err error, statusCode int, internalError bool) {
+	var validationErrs validator.ValidationErrors
+	var jsonTypeErr *json.UnmarshalTypeError
+	var jsonSyntaxErr *json.SyntaxError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(rawError, &validationErrs):
+		return errors.New(friendlyValidatorMessage(validationErrs)), 0, true
+	case errors.As(rawError, &jsonTypeErr):
+		return errors.New(jsonTypeErr.Field + " type mismatch"), 0, false
+	case errors.As(rawError, &jsonSyntaxErr):
+		return errors.New("bad json payload"), 0, false
+	case strings.HasPrefix(rawError.Error(), "json: unknown field "):
+		return errors.New("unknown field " + strings.TrimPrefix(rawError.Error(), "json: unknown field ")), http.StatusBadRequest, true
+	case errors.As(rawError, &maxBytesErr):
+		return fmt.Errorf("request body exceeds the limit of %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge, true
+	case errors.Is(rawError, multipart.ErrMessageTooLarge):
+		return errors.New("multipart form exceeds the memory limit"), http.StatusRequestEntityTooLarge, true
+	case errors.Is(rawError, http.ErrNotMultipart), errors.Is(rawError, http.ErrMissingBoundary):
+		return errors.New("request is not a multipart form"), http.StatusBadRequest, true
+	case errors.Is(rawError, errUploadBytesLimitExceeded):
+		return errors.New("too many bytes uploading, retry later"), http.StatusServiceUnavailable, true
+	case errors.Is(rawError, http.ErrMissingFile):
+		return errors.New("upload file not found"), http.StatusBadRequest, true
+	}
+	return rawError, 0, false
+}
+
 type RouterInfo struct {
 	// GroupPath 路由分组路径
 	GroupPath string
 
 	// 该Router下的中间件执行器
 	Interceptors []PreInterceptor
+
+	// 该Router下的中间件 在Interceptors之前执行 可通过 RouterWrapper.Use 为单个处理器追加
+	Middlewares []Middleware
+
+	// 该Router下请求body的最大字节数 用于上传等需要更大限制的路由 0 表示继承全局/默认设置
+	MaxBodyBytes int64
+
+	// 该Router下成功(2xx)响应的Cache-Control响应头 处理器已设置Cache-Control时不覆盖
+	// 可通过 RouterWrapper.CacheControl 为单个处理器覆盖
+	CacheControl string
+
+	// 该Router分组路径下未匹配任何路由的请求的兜底处理器 例如在 /app 下托管的SPA回退至index.html
+	// 兜底处理器同样经过该Router的Middlewares、Interceptors及MaxBodyBytes 多个分组路径嵌套时由路径最长的分组处理
+	// 不在任何设置了Fallback的分组路径下的请求仍按全局404处理 路径匹配但请求方法不匹配时仍响应405
+	Fallback HandlerWrapper
 }
 
 // RouterWrapper 定义路由包装器
 type RouterWrapper struct {
-	routerGroup *gin.RouterGroup
+	state        *engineState
+	routerGroup  *gin.RouterGroup
+	operation    string
+	cacheControl string
+	middlewares  []Middleware
+}
+
+// Operation 为接下来注册的处理器指定稳定的操作名 用于指标标签、链路span名称等观测场景 不受路径模板变化影响
+// 例如 router.Operation("getUser").GET("user/:id", handler) 请求中通过 request.OperationName() 获取
+func (r *RouterWrapper) Operation(name string) *RouterWrapper {
+	return &RouterWrapper{state: r.state, routerGroup: r.routerGroup, operation: name, cacheControl: r.cacheControl, middlewares: r.middlewares}
+}
+
+// CacheControl 为接下来注册的处理器指定成功响应的Cache-Control 覆盖RouterInfo.CacheControl
+// 例如 router.CacheControl("no-store").GET("user/:id", handler)
+func (r *RouterWrapper) CacheControl(value string) *RouterWrapper {
+	return &RouterWrapper{state: r.state, routerGroup: r.routerGroup, operation: r.operation, cacheControl: value, middlewares: r.middlewares}
+}
+
+// Use 为接下来注册的处理器追加中间件 在RouterInfo.Middlewares及Interceptors之后执行
+// 例如 router.Use(CompressionMiddleware(CompressionConfig{})).GET("export", handler)
+func (r *RouterWrapper) Use(middlewares ...Middleware) *RouterWrapper {
+	merged := make([]Middleware, 0, len(r.middlewares)+len(middlewares))
+	merged = append(append(merged, r.middlewares...), middlewares...)
+	return &RouterWrapper{state: r.state, routerGroup: r.routerGroup, operation: r.operation, cacheControl: r.cacheControl, middlewares: merged}
 }
 
 // HandlerWrapper 定义内部Handler
@@ -45,6 +154,12 @@ type Router interface {
 	Handlers(router *RouterWrapper)
 }
 
+// RouterRegisterHook 可选的路由注册钩子 Router同时实现该接口时 将在注册处理器之前调用
+// 可用于对该路由分组进行RouterInfo之外的定制 例如注册分组专属的gin中间件
+type RouterRegisterHook interface {
+	OnRegister(group *gin.RouterGroup)
+}
+
 // 定义RouterWrapper的接收请求行为
 
 func (r *RouterWrapper) POST(path string, handler ...HandlerWrapper) {
@@ -96,21 +211,52 @@ func (r *RouterWrapper) TRACE1(path string, contentType []string, handler ...Han
 }
 
 func (r *RouterWrapper) MATCH(method []string, path string, handler ...HandlerWrapper) {
-	r.handler(method, path, nil, handler...)
+	r.MATCH1(method, path, nil, handler...)
 }
 func (r *RouterWrapper) MATCH1(method []string, path string, contentType []string, handler ...HandlerWrapper) {
 	r.handler(method, path, contentType, handler...)
+	if r.state.config.AutoMatchOptions && !coll.SliceContains(method, http.MethodOptions) {
+		r.state.autoOptionsRoutes = append(r.state.autoOptionsRoutes, &autoOptionsRoute{group: r.routerGroup, path: path})
+	}
 }
 
 // 执行RouterWrapper行为
 
 func (r *RouterWrapper) handler(methods []string, path string, contentType []string, handlerWrapper ...HandlerWrapper) {
+	handlers := r.ginHandlers(contentType, handlerWrapper...)
+	if len(r.middlewares) > 0 {
+		middlewareHandlers := make([]gin.HandlerFunc, 0, len(r.middlewares)+len(handlers))
+		for _, middleware := range r.middlewares {
+			middlewareHandlers = append(middlewareHandlers, middleware.handlerFunc())
+		}
+		handlers = append(middlewareHandlers, handlers...)
+	}
+	r.routerGroup.Match(methods, path, handlers...)
+	if r.operation != "" {
+		for _, method := range methods {
+			r.state.registerOperationName(method, joinPaths(r.routerGroup.BasePath(), path), r.operation)
+		}
+	}
+	if r.state.config.AutoHead && coll.SliceContains(methods, http.MethodGet) && !coll.SliceContains(methods, http.MethodHead) {
+		r.state.autoHeadRoutes = append(r.state.autoHeadRoutes, &autoHeadRoute{group: r.routerGroup, path: path, handlers: handlers, operation: r.operation})
+	}
+}
+
+// 将HandlerWrapper转换为gin处理器
+func (r *RouterWrapper) ginHandlers(contentType []string, handlerWrapper ...HandlerWrapper) []gin.HandlerFunc {
 	handlers := make([]gin.HandlerFunc, len(handlerWrapper))
+	cacheControl := r.cacheControl
 	for i, handler := range handlerWrapper {
 		handlers[i] = func(context *gin.Context) {
 
 			if context.IsAborted() {
-				logger.Logrus().Warning("Request is aborted")
+				if resolver := configOf(context).AbortedRequestResolver; resolver != nil {
+					if response := resolver(&Request{ctx: context}); response != nil {
+						httpResponse(context, response)
+					}
+				} else {
+					logger.Logrus().Warning("Request is aborted")
+				}
 				return
 			}
 
@@ -123,8 +269,21 @@ func (r *RouterWrapper) handler(methods []string, path string, contentType []str
 				}
 			}
 
+			if cacheControl != "" {
+				writer := &cacheControlWriter{ResponseWriter: context.Writer, value: cacheControl}
+				context.Writer = writer
+				defer func() {
+					context.Writer = writer.ResponseWriter
+				}()
+			}
+
 			response, err := handler(&Request{context})
 			if err != nil {
+				var bindErr *BindError
+				if errors.As(err, &bindErr) {
+					httpResponse(context, bindErr.response())
+					return
+				}
 				panic(err)
 			}
 
@@ -135,20 +294,24 @@ func (r *RouterWrapper) handler(methods []string, path string, contentType []str
 			}
 		}
 	}
-	r.routerGroup.Match(methods, path, handlers...)
+	return handlers
 }
 
 func httpResponse(context *gin.Context, response Response) {
 	context.Set(GinCtxKeyResponse, response)
 
 	// 是否启用traceId响应
-	if ginConfig.EnableGoroutineTraceIdResponse && sys.IsEnabledLocalTraceId() {
+	config := configOf(context)
+	if config.EnableGoroutineTraceIdResponse && sys.IsEnabledLocalTraceId() && !isTraceIdDisabled(context, response) {
 		context.Header("Trace-Id", sys.GetLocalTraceId())
 	}
 
 	// 如果是普通响应 判断是否使用了gin原始响应功能
 	if instance, ok := response.(*commonResp); ok {
 		if instance.ginFn != nil {
+			if instance.responseData != nil {
+				logger.Logrus().Warningln("Response has both gin function and response data, response data is ignored path:", context.Request.URL)
+			}
 			instance.ginFn(context)
 			return
 		}
@@ -158,6 +321,7 @@ func httpResponse(context *gin.Context, response Response) {
 	if responseData == nil {
 		return
 	}
+	recordResponseSchema(context, responseData.schemaType)
 
 	contentType := responseData.contentType
 	if contentType == "" {
@@ -169,23 +333,126 @@ func httpResponse(context *gin.Context, response Response) {
 		httpStatusCode = http.StatusOK
 	}
 
-	cookies := responseData.cookies
+	cookies := truncateResponseItems(context, responseData.cookies, "cookies", responseCookieSize)
 	if len(cookies) > 0 {
 		for _, v := range cookies {
+			if v.maxAge < 0 {
+				// 删除Cookie 同时设置过期时间以兼容不支持Max-Age的客户端
+				path := v.path
+				if path == "" {
+					path = "/"
+				}
+				http.SetCookie(context.Writer, &http.Cookie{
+					Name:     v.name,
+					MaxAge:   -1,
+					Expires:  time.Unix(0, 0),
+					Path:     path,
+					Domain:   v.domain,
+					Secure:   v.secure,
+					HttpOnly: v.httpOnly,
+				})
+				continue
+			}
 			context.SetCookie(v.name, v.value, v.maxAge, v.path, v.domain, v.secure, v.httpOnly)
 		}
 	}
 
-	headers := responseData.headers
+	headers := truncateResponseItems(context, responseData.headers, "headers", responseHeaderSize)
 	if len(headers) > 0 {
 		for _, v := range headers {
 			context.Header(v.name, v.value)
 		}
 	}
 
+	if responseData.closeConnection {
+		closeConnectionAfterResponse(context)
+	}
+
 	data := responseData.data
+	if responseData.hasPayload {
+		bytes, err := responseDataStructDecoder(context).Decode(responseData.payload)
+		if err != nil {
+			panic(err)
+		}
+		data = bytes
+	}
 	if len(data) > 0 {
 		context.Data(httpStatusCode, contentType, data)
+		return
+	}
+	// 空响应体 仅响应状态码及已设置的响应头/Cookie 或按配置响应默认的Rest成功结构
+	if config.EmptyBodyRestResponse && httpStatusCode == http.StatusOK {
+		bodyBytes, err := responseDataStructDecoder(context).Decode(NewRestSuccess())
+		if err == nil {
+			context.Data(httpStatusCode, gin.MIMEJSON, bodyBytes)
+			return
+		}
+	}
+	context.Status(httpStatusCode)
+}
+
+const (
+	defaultMaxResponseHeaders     = 100
+	defaultMaxResponseHeaderBytes = 64 * 1024
+)
+
+// 按MaxResponseHeaders及MaxResponseHeaderBytes截断响应头/Cookie size 计算单项写出的字节数
+func truncateResponseItems[T any](context *gin.Context, items []T, kind string, size func(item T) int) []T {
+	config := configOf(context)
+	maxCount := config.MaxResponseHeaders
+	if maxCount == 0 {
+		maxCount = defaultMaxResponseHeaders
+	}
+	if maxCount >= 0 && len(items) > maxCount {
+		logger.Logrus().Warningln("Too many response", kind, len(items), "truncated to", maxCount, "path:", context.Request.URL)
+		items = items[:maxCount]
+	}
+	maxBytes := config.MaxResponseHeaderBytes
+	if maxBytes == 0 {
+		maxBytes = defaultMaxResponseHeaderBytes
+	}
+	if maxBytes < 0 {
+		return items
+	}
+	total := 0
+	for i, item := range items {
+		total += size(item)
+		if total > maxBytes {
+			logger.Logrus().Warningln("Response", kind, "exceed", maxBytes, "bytes truncated to", i, "items path:", context.Request.URL)
+			return items[:i]
+		}
+	}
+	return items
+}
+
+// 响应头写出的近似字节数 name: value\r\n
+func responseHeaderSize(header *ResponseHeader) int {
+	return len(header.name) + len(header.value) + 4
+}
+
+// Cookie写出的近似字节数 包含Set-Cookie头名称及常用属性的预留
+func responseCookieSize(cookie *ResponseCookie) int {
+	return len("Set-Cookie: \r\n") + len(cookie.name) + len(cookie.value) + len(cookie.path) + len(cookie.domain) + 64
+}
+
+// 当前响应是否禁用了TraceId响应头
+func isTraceIdDisabled(context *gin.Context, response Response) bool {
+	if context.GetBool(ginCtxKeyNoTraceId) {
+		return true
+	}
+	if instance, ok := response.(*commonResp); ok && instance.ginFn != nil {
+		return false
+	}
+	responseData := response.Data()
+	return responseData != nil && responseData.disableTraceId
+}
+
+// 设置Connection: close 由net/http在写出响应后关闭HTTP/1.x连接 即使服务启用了keep-alive
+// 响应头在最终写出时才发送 因此对可重写状态码等缓存响应的写入器同样生效
+// HTTP/2不允许Connection响应头 将忽略该设置
+func closeConnectionAfterResponse(context *gin.Context) {
+	if context.Request.ProtoMajor == 1 {
+		context.Header("Connection", "close")
 	}
 }
 
@@ -194,16 +461,40 @@ type responseRewriter struct {
 	gin.ResponseWriter
 	body       *bytes.Buffer
 	statusCode int
+	// 处理器调用Flush后不再缓存响应体 直接输出 用于流式响应
+	passthrough bool
 }
 
 func (r *responseRewriter) WriteHeader(code int) {
+	if r.passthrough {
+		return
+	}
 	r.statusCode = code
 }
 
 func (r *responseRewriter) Write(data []byte) (int, error) {
+	if r.passthrough {
+		return r.ResponseWriter.Write(data)
+	}
 	return r.body.Write(data)
 }
 
+// Flush 流式响应 输出状态码及已缓存的内容 此后的写入直接输出
+func (r *responseRewriter) Flush() {
+	if !r.passthrough {
+		r.passthrough = true
+		if r.statusCode == 0 {
+			r.statusCode = r.ResponseWriter.Status()
+		}
+		r.ResponseWriter.WriteHeader(r.statusCode)
+		if r.body.Len() > 0 {
+			_, _ = r.ResponseWriter.Write(r.body.Bytes())
+			r.body.Reset()
+		}
+	}
+	r.ResponseWriter.Flush()
+}
+
 func (r *responseRewriter) WriteHeaderNow() {
 	if !r.Written() {
 		r.ResponseWriter.WriteHeader(r.statusCode)
@@ -214,6 +505,46 @@ func (r *responseRewriter) Status() int {
 	return r.statusCode
 }
 
+// 为成功响应设置Cache-Control的响应写入器 在确定响应状态码时设置
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	value   string
+	applied bool
+}
+
+func (w *cacheControlWriter) apply(code int) {
+	if w.applied {
+		return
+	}
+	w.applied = true
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if code >= http.StatusOK && code < http.StatusMultipleChoices && w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", w.value)
+	}
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	w.apply(code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.apply(w.Status())
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(data []byte) (int, error) {
+	w.apply(w.Status())
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.apply(w.Status())
+	return w.ResponseWriter.WriteString(s)
+}
+
 // ResponseData 标准响应数据内容
 type ResponseData struct {
 	// body响应体负载数据
@@ -226,6 +557,15 @@ type ResponseData struct {
 	headers []*ResponseHeader
 	// 响应Cookie
 	cookies []*ResponseCookie
+	// 响应后关闭连接
+	closeConnection bool
+	// 不响应TraceId响应头
+	disableTraceId bool
+	// 调试模式下记录的响应数据类型
+	schemaType string
+	// Rest响应的结构体数据 响应时使用当前请求的解码器编码
+	payload    any
+	hasPayload bool
 }
 
 // ResponseHeader 响应头
@@ -237,8 +577,9 @@ type ResponseHeader struct {
 
 // ResponseCookie 响应Cookie
 type ResponseCookie struct {
-	name     string
-	value    string
+	name  string
+	value string
+	// 0 表示会话Cookie 小于0 表示立即删除该Cookie
 	maxAge   int
 	path     string
 	domain   string
@@ -273,8 +614,15 @@ func NewCookie(name, value string, maxAge int, path, domain string, secure, http
 	return &ResponseCookie{name: name, value: value, maxAge: maxAge, path: path, domain: domain, secure: secure, httpOnly: httpOnly}
 }
 
+// ExpireCookie 创建一个用于删除客户端Cookie的响应Cookie path/domain需要与设置时保持一致
+func ExpireCookie(name, path, domain string) *ResponseCookie {
+	return &ResponseCookie{name: name, maxAge: -1, path: path, domain: domain}
+}
+
 func (r *ResponseData) SetData(data []byte) *ResponseData {
 	r.data = data
+	r.payload = nil
+	r.hasPayload = false
 	return r
 }
 
@@ -333,8 +681,24 @@ func (r *ResponseData) AddCookie(cookie *ResponseCookie) *ResponseData {
 	return r
 }
 
+// CloseConnection 响应完成后关闭连接 设置Connection: close响应头 仅对HTTP/1.x生效
+func (r *ResponseData) CloseConnection() *ResponseData {
+	r.closeConnection = true
+	return r
+}
+
+// DisableTraceId 不响应TraceId响应头 即使启用了EnableGoroutineTraceIdResponse 适用于公开或可缓存的接口
+func (r *ResponseData) DisableTraceId() *ResponseData {
+	r.disableTraceId = true
+	return r
+}
+
 func (r *ResponseData) ToDebugString() string {
-	return fmt.Sprintf("body: %s head: %v content-type: %s", string(r.data), r.headers, r.contentType)
+	data := r.data
+	if r.hasPayload {
+		data, _ = currentConfig().ResponseDataStructDecoder.Decode(r.payload)
+	}
+	return fmt.Sprintf("body: %s head: %v content-type: %s", string(data), r.headers, r.contentType)
 }
 
 // PreInterceptor 前置拦截器